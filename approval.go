@@ -0,0 +1,98 @@
+package main
+
+import "fmt"
+
+// transferRequest is a transfer awaiting approval, see RequestTransfer.
+type transferRequest struct {
+	userID int // User who requested the transfer
+	fromID int
+	toID   int
+	amount float64
+}
+
+// SetApprovalThreshold makes transfers requested with RequestTransfer for more than
+// amount wait for approval. A threshold of zero executes every request immediately.
+func (b *BankService) SetApprovalThreshold(amount float64) error {
+	if amount < 0 {
+		return ErrInvalidAmount
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.approvalThreshold = amount
+	fmt.Printf("Set transfer approval threshold: %.2f\n", amount)
+	return nil
+}
+
+// RequestTransfer transfers funds like TransferWithMemo without a memo if the amount
+// is within the approval threshold, and returns a zero request ID. Larger transfers
+// are left pending until approved with ApproveTransfer, and their request ID is
+// returned instead.
+func (b *BankService) RequestTransfer(userID, fromID, toID int, amount float64) (int64, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return 0, err
+	}
+	if err := b.checkTransactionLimit(userID, OpTransfer, amount); err != nil {
+		return 0, err
+	}
+
+	b.mutex.Lock()
+	threshold := b.approvalThreshold
+	b.mutex.Unlock()
+
+	if threshold == 0 || amount <= threshold {
+		_, err := b.transfer(userID, fromID, toID, amount, "", nil)
+		return 0, err
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return 0, err
+	}
+	if b.lookupAccount(fromID).currency != toAccount.currency {
+		return 0, ErrCurrencyMismatch
+	}
+
+	b.mutex.Lock()
+	b.nextRequestID++
+	requestID := b.nextRequestID
+	b.approvals[requestID] = &transferRequest{userID: userID, fromID: fromID, toID: toID, amount: amount}
+	b.mutex.Unlock()
+
+	fmt.Printf("User %d requested transfer %d of %.2f from account %d to account %d, awaiting approval\n",
+		userID, requestID, amount, fromID, toID)
+	return requestID, nil
+}
+
+// ApproveTransfer executes a pending transfer request. Only a Banker other than the
+// user who requested the transfer can approve it. The request is consumed even if
+// the transfer then fails, e.g. for lack of funds.
+func (b *BankService) ApproveTransfer(approverID int, requestID int64) error {
+	b.mutex.Lock()
+	request, exists := b.approvals[requestID]
+	if !exists {
+		b.mutex.Unlock()
+		return ErrTransferRequestNotFound
+	}
+	if approver, ok := b.users[approverID]; !ok || approver.Role != Banker || approverID == request.userID {
+		b.mutex.Unlock()
+		return userError(approverID, ErrUnauthorizedAccess)
+	}
+	delete(b.approvals, requestID)
+	b.mutex.Unlock()
+
+	if err := b.checkActive(approverID); err != nil {
+		return err
+	}
+	// The requester may have lost access to the source since asking.
+	if err := b.checkDebitPermissions(request.userID, request.fromID); err != nil {
+		return err
+	}
+	fmt.Printf("User %d approved transfer %d\n", approverID, requestID)
+	_, err := b.transfer(request.userID, request.fromID, request.toID, request.amount, "", nil)
+	return err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRequestTransferBelowThreshold ensures transfers within the threshold execute
+// immediately.
+func TestRequestTransferBelowThreshold(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	fromID, _ := bank.CreateAccount(1, 1000, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetApprovalThreshold(500)
+
+	requestID, err := bank.RequestTransfer(1, fromID, toID, 500)
+	if err != nil || requestID != 0 {
+		t.Fatalf("expected immediate transfer, got request %d and error %v", requestID, err)
+	}
+	if balance, _, _ := bank.GetBalance(1, toID); balance != 500 {
+		t.Errorf("expected balance 500, got %.2f", balance)
+	}
+}
+
+// TestRequestTransferAboveThreshold ensures larger transfers wait for approval by
+// another user who is a Banker.
+func TestRequestTransferAboveThreshold(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+	bank.CreateUser(3, Teller, false)
+	fromID, _ := bank.CreateAccount(1, 1000, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetApprovalThreshold(500)
+
+	requestID, err := bank.RequestTransfer(1, fromID, toID, 800)
+	if err != nil || requestID == 0 {
+		t.Fatalf("expected a pending request, got request %d and error %v", requestID, err)
+	}
+	if balance, _, _ := bank.GetBalance(1, toID); balance != 0 {
+		t.Fatalf("expected no funds moved before approval, got balance %.2f", balance)
+	}
+
+	for _, userID := range []int{1, 3} {
+		if err := bank.ApproveTransfer(userID, requestID); !errors.Is(err, ErrUnauthorizedAccess) {
+			t.Errorf("expected user %d to be refused, got %v", userID, err)
+		}
+	}
+
+	if err := bank.ApproveTransfer(2, requestID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	fromBalance, _, _ := bank.GetBalance(1, fromID)
+	toBalance, _, _ := bank.GetBalance(1, toID)
+	if fromBalance != 200 || toBalance != 800 {
+		t.Errorf("expected balances 200 and 800, got %.2f and %.2f", fromBalance, toBalance)
+	}
+
+	if err := bank.ApproveTransfer(2, requestID); !errors.Is(err, ErrTransferRequestNotFound) {
+		t.Errorf("expected ErrTransferRequestNotFound, got %v", err)
+	}
+}
+
+// TestApproveTransferAfterOwnershipChange ensures a request isn't executed once the
+// requester can no longer debit the source account.
+func TestApproveTransferAfterOwnershipChange(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	bank.CreateUser(3, Banker, false)
+	fromID, _ := bank.CreateAccount(1, 1000, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetApprovalThreshold(100)
+
+	requestID, _ := bank.RequestTransfer(1, fromID, toID, 500)
+	if err := bank.TransferOwnership(1, fromID, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := bank.ApproveTransfer(3, requestID); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(2, fromID); balance != 1000 {
+		t.Errorf("expected balance 1000, got %.2f", balance)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// maxCashAmount is the largest amount WithdrawCash pays out at once. It bounds the
+// memory used to work out the breakdown.
+const maxCashAmount = 100000
+
+// WithdrawCash withdraws like Withdraw and returns how to pay out the amount as a
+// count of bills or coins by denomination, using as few as possible. Only whole
+// amounts up to maxCashAmount are supported. If the amount can't be made exactly
+// from the denominations, it fails with ErrUnbreakableAmount before debiting
+// anything. Cash withdrawals are never partial, whatever the user's setting.
+func (b *BankService) WithdrawCash(userID, accountID int, amount float64, denominations []int) (map[int]int, error) {
+	if amount <= 0 || amount > maxCashAmount || slices.ContainsFunc(denominations, func(d int) bool { return d <= 0 }) {
+		return nil, ErrInvalidAmount
+	}
+	if amount != math.Trunc(amount) {
+		return nil, ErrUnbreakableAmount
+	}
+	// Check the withdrawal is allowed before spending time and memory on the breakdown.
+	if err := b.CanWithdraw(userID, accountID, amount); err != nil {
+		return nil, err
+	}
+
+	notes, ok := breakDown(int(amount), denominations)
+	if !ok {
+		return nil, ErrUnbreakableAmount
+	}
+
+	if _, err := b.withdraw(userID, accountID, amount, "", anyVersion, false); err != nil {
+		return nil, err
+	}
+	fmt.Printf("User %d withdrew %.2f in cash from account %d: %v\n", userID, amount, accountID, notes)
+	return notes, nil
+}
+
+// breakDown finds the fewest bills or coins from denominations adding up to amount
+// and returns their count by denomination. It reports false if there is no exact
+// breakdown.
+func breakDown(amount int, denominations []int) (map[int]int, bool) {
+	// fewest[n] is the fewest notes making up n, and last[n] the denomination of
+	// one of them. Greedy choice isn't enough for denominations such as {3, 4}.
+	fewest := make([]int, amount+1)
+	last := make([]int, amount+1)
+	for n := 1; n <= amount; n++ {
+		fewest[n] = -1
+		for _, d := range denominations {
+			if d <= n && fewest[n-d] >= 0 && (fewest[n] < 0 || fewest[n-d]+1 < fewest[n]) {
+				fewest[n] = fewest[n-d] + 1
+				last[n] = d
+			}
+		}
+	}
+	if fewest[amount] < 0 {
+		return nil, false
+	}
+
+	notes := make(map[int]int)
+	for n := amount; n > 0; n -= last[n] {
+		notes[last[n]]++
+	}
+	return notes, true
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+// TestWithdrawCash ensures the amount is debited and broken down into the fewest
+// bills.
+func TestWithdrawCash(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	notes, err := bank.WithdrawCash(1, accID, 180, []int{100, 50, 20, 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := map[int]int{100: 1, 50: 1, 20: 1, 10: 1}
+	if !maps.Equal(notes, expected) {
+		t.Errorf("expected breakdown %v, got %v", expected, notes)
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 320 {
+		t.Errorf("expected balance 320, got %.2f", balance)
+	}
+
+	// Greedy choice would take a 4 and get stuck on the remaining 2.
+	notes, _ = bank.WithdrawCash(1, accID, 6, []int{4, 3})
+	if expected := map[int]int{3: 2}; !maps.Equal(notes, expected) {
+		t.Errorf("expected breakdown %v, got %v", expected, notes)
+	}
+}
+
+// TestWithdrawCashUnbreakable ensures amounts that can't be paid out exactly are
+// rejected without debiting the account.
+func TestWithdrawCashUnbreakable(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	for _, amount := range []float64{35, 40.5} {
+		if _, err := bank.WithdrawCash(1, accID, amount, []int{20, 10}); !errors.Is(err, ErrUnbreakableAmount) {
+			t.Errorf("expected ErrUnbreakableAmount for %.2f, got %v", amount, err)
+		}
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 500 {
+		t.Errorf("expected balance 500, got %.2f", balance)
+	}
+}
+
+// TestWithdrawCashChecksFirst ensures a cash withdrawal that isn't allowed, or is
+// too large to pay out, is rejected before its breakdown is worked out.
+func TestWithdrawCashChecksFirst(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	tests := []struct {
+		name     string
+		userID   int
+		amount   float64
+		expected error
+	}{
+		{"unauthorized", 2, 100, ErrUnauthorizedAccess},
+		{"insufficient", 1, 1000, ErrInsufficientBalance},
+		{"too large", 1, 1e15, ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		if _, err := bank.WithdrawCash(tt.userID, accID, tt.amount, []int{20, 10}); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 500 {
+		t.Errorf("expected balance 500, got %.2f", balance)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetAllowedCategories restricts transaction categories to the given set. Calling it
+// without categories allows any category.
+func (b *BankService) SetAllowedCategories(categories ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(categories) == 0 {
+		b.categories = nil
+		return
+	}
+	b.categories = make(map[string]bool, len(categories))
+	for _, category := range categories {
+		b.categories[category] = true
+	}
+	fmt.Printf("Set allowed transaction categories: %v\n", categories)
+}
+
+// checkCategory verifies the category is allowed.
+func (b *BankService) checkCategory(category string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.categories != nil && !b.categories[category] {
+		return ErrUnknownCategory
+	}
+	return nil
+}
+
+// DepositCategorized deposits like Deposit and tags the transaction with category.
+func (b *BankService) DepositCategorized(userID, accountID int, amount float64, category string) error {
+	if err := b.checkCategory(category); err != nil {
+		return err
+	}
+	_, err := b.deposit(userID, accountID, Transaction{Type: TxDeposit, Amount: amount, Counterparty: noCounterparty, Category: category})
+	return err
+}
+
+// WithdrawCategorized withdraws like Withdraw and tags every resulting transaction,
+// including those on backup accounts, with category.
+func (b *BankService) WithdrawCategorized(userID, accountID int, amount float64, category string) error {
+	if err := b.checkCategory(category); err != nil {
+		return err
+	}
+	_, err := b.withdraw(userID, accountID, amount, category, anyVersion, true)
+	return err
+}
+
+// SpendingByCategory sums the account's outflows in [from, to) by category.
+// Uncategorized outflows are summed under the empty category.
+func (b *BankService) SpendingByCategory(userID, accountID int, from, to time.Time) (map[string]float64, error) {
+	transactions, err := b.QueryTransactions(userID, accountID, TransactionFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	spending := make(map[string]float64)
+	for _, tx := range transactions {
+		if tx.Type.isOutflow() {
+			spending[tx.Category] += tx.Amount
+		}
+	}
+	return spending, nil
+}
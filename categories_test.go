@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+// TestSpendingByCategory ensures outflows are summed by category within the window.
+func TestSpendingByCategory(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.SetAllowedCategories("groceries", "rent", "salary")
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+	otherID, _ := bank.CreateAccount(1, 0, USD)
+	start := clock.Now()
+
+	bank.DepositCategorized(1, accID, 500, "salary")
+	bank.WithdrawCategorized(1, accID, 40, "groceries")
+	bank.WithdrawCategorized(1, accID, 600, "rent")
+	bank.WithdrawCategorized(1, accID, 25, "groceries")
+	bank.Transfer(accID, otherID, 10)
+	clock.Advance(day)
+	bank.WithdrawCategorized(1, accID, 5, "groceries")
+
+	if err := bank.WithdrawCategorized(1, accID, 5, "gambling"); !errors.Is(err, ErrUnknownCategory) {
+		t.Fatalf("expected ErrUnknownCategory, got %v", err)
+	}
+
+	spending, err := bank.SpendingByCategory(1, accID, start, clock.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := map[string]float64{"groceries": 65, "rent": 600, "": 10}
+	if !maps.Equal(spending, expected) {
+		t.Errorf("expected %v, got %v", expected, spending)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import "fmt"
+
+// CloseAccount closes an account so it accepts no further transactions. Only the
+// owner or a Banker can close an account, which must be unfrozen, empty and free of
+// holds. Closing revokes any overdraft.
+func (b *BankService) CloseAccount(userID, accountID int) error {
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if err := account.checkClosable(accountID); err != nil {
+		return err
+	}
+
+	account.closed = true
+	account.overdraftLimit = 0
+	fmt.Printf("User %d closed account %d\n", userID, accountID)
+	return nil
+}
+
+// CanCloseAccount runs the checks of CloseAccount without closing the account,
+// returning the reason closure is blocked, if any.
+func (b *BankService) CanCloseAccount(userID, accountID int) error {
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	return account.checkClosable(accountID)
+}
+
+// checkClosable verifies the account is open, unfrozen, free of holds and empty in
+// every currency it holds. The caller must hold the account lock.
+func (a *Account) checkClosable(accountID int) error {
+	if a.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	if a.frozen {
+		return accountError(accountID, ErrAccountFrozen)
+	}
+	if len(a.activeHolds) > 0 {
+		return accountError(accountID, ErrActiveHolds)
+	}
+	if a.balance != 0 {
+		return accountError(accountID, ErrAccountNotEmpty)
+	}
+	for _, balance := range a.buckets {
+		if balance != 0 {
+			return accountError(accountID, ErrAccountNotEmpty)
+		}
+	}
+	return nil
+}
+
+// SetClosedAccountFallback redirects deposits to accountID, once it is closed, to
+// fallbackID instead of rejecting them. Both accounts must share a currency and the
+// fallback must be open.
+func (b *BankService) SetClosedAccountFallback(accountID, fallbackID int) error {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+	fallback, err := b.getAccount(fallbackID)
+	if err != nil {
+		return err
+	}
+	if account.currency != fallback.currency {
+		return ErrCurrencyMismatch
+	}
+
+	fallback.mutex.RLock()
+	closed := fallback.closed
+	fallback.mutex.RUnlock()
+	if closed || fallbackID == accountID {
+		return accountError(fallbackID, ErrAccountClosed)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closedFallbacks[accountID] = fallbackID
+	fmt.Printf("Set fallback for account %d: account %d\n", accountID, fallbackID)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCloseAccount ensures only empty accounts can be closed.
+func TestCloseAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	if err := bank.CloseAccount(1, accID); !errors.Is(err, ErrAccountNotEmpty) {
+		t.Fatalf("expected ErrAccountNotEmpty, got %v", err)
+	}
+
+	bank.Withdraw(1, accID, 100)
+	if err := bank.CloseAccount(1, accID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.CloseAccount(1, accID); !errors.Is(err, ErrAccountClosed) {
+		t.Errorf("expected ErrAccountClosed, got %v", err)
+	}
+}
+
+// TestDebitClosedAccount ensures closed accounts can't be debited or given an
+// overdraft, and dry runs agree.
+func TestDebitClosedAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+	otherID, _ := bank.CreateAccount(1, 100, USD)
+	bank.EnableMultiCurrency(1, accID)
+	bank.SetExchangeRate(USD, EUR, 0.9)
+	bank.CloseAccount(1, accID)
+
+	checks := map[string]error{
+		"SetOverdraftLimit":     bank.SetOverdraftLimit(accID, 100),
+		"SetOverdraftRate":      bank.SetOverdraftRate(accID, 0.01),
+		"SetInterestRate":       bank.SetInterestRate(accID, 0.05),
+		"Withdraw":              bank.Withdraw(1, accID, 50),
+		"CanWithdraw":           bank.CanWithdraw(1, accID, 50),
+		"CanTransfer":           bank.CanTransfer(otherID, accID, 50),
+		"TransferCurrency":      bank.TransferCurrency(1, accID, otherID, 50, USD),
+		"ExchangeWithinAccount": bank.ExchangeWithinAccount(1, accID, 50, USD, EUR),
+	}
+	_, checks["WithdrawToExternal"] = bank.WithdrawToExternal(1, accID, 50, "WIRE-1")
+	_, checks["PlaceHold"] = bank.PlaceHold(1, accID, 50)
+
+	for name, err := range checks {
+		if !errors.Is(err, ErrAccountClosed) {
+			t.Errorf("%s: expected ErrAccountClosed, got %v", name, err)
+		}
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 0 {
+		t.Errorf("expected balance 0, got %.2f", balance)
+	}
+}
+
+// TestCanCloseAccount ensures the closure check reports each blocking reason
+// without closing the account.
+func TestCanCloseAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Teller, false)
+
+	empty, _ := bank.CreateAccount(1, 0, USD)
+	funded, _ := bank.CreateAccount(1, 100, USD)
+	frozen, _ := bank.CreateAccount(1, 0, USD)
+	bank.FreezeAccount(2, frozen)
+	held, _ := bank.CreateAccount(1, 100, USD)
+	bank.PlaceHold(1, held, 50)
+	closed, _ := bank.CreateAccount(1, 0, USD)
+	bank.CloseAccount(1, closed)
+	wallet, _ := bank.CreateAccount(1, 0, USD)
+	bank.EnableMultiCurrency(1, wallet)
+	bank.DepositCurrency(1, wallet, 50, EUR)
+
+	tests := []struct {
+		name      string
+		accountID int
+		expected  error
+	}{
+		{"empty", empty, nil},
+		{"balance", funded, ErrAccountNotEmpty},
+		{"other currency", wallet, ErrAccountNotEmpty},
+		{"frozen", frozen, ErrAccountFrozen},
+		{"holds", held, ErrActiveHolds},
+		{"closed", closed, ErrAccountClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bank.CanCloseAccount(1, tt.accountID); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if info, _ := bank.GetAccountInfo(1, empty); info.Closed {
+		t.Error("expected the check not to close the account")
+	}
+}
+
+// TestDepositToClosedAccount ensures deposits to a closed account are rejected.
+func TestDepositToClosedAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	closedID, _ := bank.CreateAccount(1, 0, USD)
+	openID, _ := bank.CreateAccount(1, 100, USD)
+	bank.CloseAccount(1, closedID)
+
+	if err := bank.Deposit(1, closedID, 50); !errors.Is(err, ErrAccountClosed) {
+		t.Fatalf("expected ErrAccountClosed, got %v", err)
+	}
+	if err := bank.Transfer(openID, closedID, 50); !errors.Is(err, ErrAccountClosed) {
+		t.Fatalf("expected ErrAccountClosed, got %v", err)
+	}
+}
+
+// TestDepositToClosedAccountFallback ensures deposits to a closed account are
+// redirected to its fallback account.
+func TestDepositToClosedAccountFallback(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	closedID, _ := bank.CreateAccount(1, 0, USD)
+	fallbackID, _ := bank.CreateAccount(1, 100, USD)
+	eurID, _ := bank.CreateAccount(1, 0, EUR)
+
+	if err := bank.SetClosedAccountFallback(closedID, eurID); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	if err := bank.SetClosedAccountFallback(closedID, fallbackID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	bank.CloseAccount(1, closedID)
+
+	if err := bank.Deposit(1, closedID, 50); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	closedBalance, _, _ := bank.GetBalance(1, closedID)
+	fallbackBalance, _, _ := bank.GetBalance(1, fallbackID)
+	if closedBalance != 0 || fallbackBalance != 150 {
+		t.Errorf("expected balances 0 and 150, got %.2f and %.2f", closedBalance, fallbackBalance)
+	}
+}
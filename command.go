@@ -0,0 +1,77 @@
+package main
+
+// Operations accepted by Execute in addition to the transactional ones
+const (
+	OpBalance       Operation = "balance" // Read-only balance query
+	OpCreateUser    Operation = "create_user"
+	OpCreateAccount Operation = "create_account"
+	OpSetRate       Operation = "set_rate"
+)
+
+// Command is a single bank operation decoded from JSON, e.g.
+// {"op":"transfer","user":1,"from":1,"to":2,"amount":100}.
+type Command struct {
+	Op      Operation `json:"op"`
+	UserID  int       `json:"user"`
+	Account int       `json:"account"` // Account for deposit, withdraw and balance
+	From    int       `json:"from"`    // Source account for transfer and exchange
+	To      int       `json:"to"`      // Destination account for transfer and exchange
+	Amount  float64   `json:"amount"`
+	Memo    string    `json:"memo,omitempty"`
+
+	Role        Role   `json:"role,omitempty"`         // Role of a new user
+	BackupFunds bool   `json:"backup_funds,omitempty"` // Whether a new user may draw on backup accounts
+	Currency    string `json:"currency,omitempty"`     // Currency of a new account, or to convert from for set_rate
+
+	// Exchange rate set by set_rate, from Currency to ToCurrency
+	ToCurrency string  `json:"to_currency,omitempty"`
+	Rate       float64 `json:"rate,omitempty"`
+}
+
+// Result is the outcome of an executed command, suitable for encoding as JSON.
+type Result struct {
+	AccountID     int     `json:"account_id,omitempty"`     // ID of a new account
+	TransactionID int64   `json:"transaction_id,omitempty"` // Debit transaction of a transfer
+	Amount        float64 `json:"amount,omitempty"`         // Amount actually withdrawn
+	Balance       float64 `json:"balance,omitempty"`
+	Currency      string  `json:"currency,omitempty"`
+}
+
+// Execute dispatches a command to the matching bank method on behalf of cmd.UserID.
+// With a journal set, mutating commands are journaled around their execution.
+func (b *BankService) Execute(cmd Command) (Result, error) {
+	if cmd.Op == OpBalance {
+		return b.execute(cmd)
+	}
+	return b.executeJournaled(cmd)
+}
+
+// execute dispatches a command without journaling it.
+func (b *BankService) execute(cmd Command) (Result, error) {
+	var result Result
+	var err error
+
+	switch cmd.Op {
+	case OpCreateUser:
+		err = b.CreateUser(cmd.UserID, cmd.Role, cmd.BackupFunds)
+	case OpCreateAccount:
+		result.AccountID, err = b.CreateAccount(cmd.UserID, cmd.Amount, cmd.Currency)
+	case OpDeposit:
+		err = b.Deposit(cmd.UserID, cmd.Account, cmd.Amount)
+	case OpWithdraw:
+		var withdrawal WithdrawResult
+		withdrawal, err = b.WithdrawDetailed(cmd.UserID, cmd.Account, cmd.Amount)
+		result.Amount = withdrawal.Total()
+	case OpTransfer:
+		result.TransactionID, err = b.TransferWithMemo(cmd.UserID, cmd.From, cmd.To, cmd.Amount, cmd.Memo)
+	case OpExchange:
+		err = b.ExchangeCurrency(cmd.UserID, cmd.From, cmd.To, cmd.Amount)
+	case OpSetRate:
+		err = b.SetExchangeRate(cmd.Currency, cmd.ToCurrency, cmd.Rate)
+	case OpBalance:
+		result.Balance, result.Currency, err = b.GetBalance(cmd.UserID, cmd.Account)
+	default:
+		err = ErrUnknownOperation
+	}
+	return result, err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestExecute ensures JSON commands are dispatched to the matching operations.
+func TestExecute(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	blobs := []string{
+		`{"op":"deposit","user":1,"account":0,"amount":50}`,
+		`{"op":"transfer","user":1,"from":0,"to":1,"amount":100}`,
+		`{"op":"withdraw","user":1,"account":1,"amount":30}`,
+	}
+	for _, blob := range blobs {
+		var cmd Command
+		if err := json.Unmarshal([]byte(blob), &cmd); err != nil {
+			t.Fatalf("expected no error decoding %s, got %v", blob, err)
+		}
+		if _, err := bank.Execute(cmd); err != nil {
+			t.Fatalf("expected no error executing %s, got %v", blob, err)
+		}
+	}
+
+	result, err := bank.Execute(Command{Op: OpBalance, UserID: 1, Account: acc2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Balance != 70 || result.Currency != USD {
+		t.Errorf("expected balance 70 USD, got %.2f %s", result.Balance, result.Currency)
+	}
+	if balance, _, _ := bank.GetBalance(1, acc1); balance != 50 {
+		t.Errorf("expected balance 50, got %.2f", balance)
+	}
+
+	encoded, _ := json.Marshal(result)
+	if string(encoded) != `{"balance":70,"currency":"USD"}` {
+		t.Errorf("unexpected result encoding %s", encoded)
+	}
+
+	if _, err := bank.Execute(Command{Op: "close"}); !errors.Is(err, ErrUnknownOperation) {
+		t.Errorf("expected ErrUnknownOperation, got %v", err)
+	}
+}
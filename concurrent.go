@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// TransferLeg is a single independent transfer executed by TransferConcurrent.
+type TransferLeg struct {
+	FromID int
+	ToID   int
+	Amount float64
+}
+
+// TransferConcurrent executes independent transfer legs on a pool of at most
+// parallelism workers and returns each leg's error at the leg's index. Unlike an
+// atomic batch, legs succeed or fail on their own. Legs not started before ctx is
+// cancelled fail with the context's error. Legs sharing accounts are safe because
+// every transfer locks its accounts in ascending ID order.
+func (b *BankService) TransferConcurrent(ctx context.Context, legs []TransferLeg, parallelism int) []error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	errs := make([]error, len(legs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				leg := legs[i]
+				errs[i] = b.Transfer(leg.FromID, leg.ToID, leg.Amount)
+			}
+		}()
+	}
+
+	for i := range legs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
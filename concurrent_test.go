@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestTransferConcurrent ensures many legs over shared accounts conserve the total.
+func TestTransferConcurrent(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	var accounts []int
+	for i := 0; i < 4; i++ {
+		accID, _ := bank.CreateAccount(1, 1000, USD)
+		accounts = append(accounts, accID)
+	}
+
+	var legs []TransferLeg
+	for i := 0; i < 200; i++ {
+		legs = append(legs, TransferLeg{
+			FromID: accounts[i%4],
+			ToID:   accounts[(i+1+i/4)%4],
+			Amount: 5,
+		})
+	}
+
+	errs := bank.TransferConcurrent(context.Background(), legs, 3)
+	if len(errs) != len(legs) {
+		t.Fatalf("expected %d results, got %d", len(legs), len(errs))
+	}
+
+	total := 0.0
+	for _, accID := range accounts {
+		balance, _, _ := bank.GetBalance(1, accID)
+		total += balance
+	}
+	if total != 4000 {
+		t.Errorf("expected total balance 4000, got %.2f", total)
+	}
+}
+
+// TestTransferConcurrentCancelled ensures legs fail with the context error once cancelled.
+func TestTransferConcurrentCancelled(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errs := bank.TransferConcurrent(ctx, []TransferLeg{{FromID: acc1, ToID: acc2, Amount: 100}}, 2)
+	if !errors.Is(errs[0], context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", errs[0])
+	}
+
+	balance, _, _ := bank.GetBalance(1, acc1)
+	if balance != 1000 {
+		t.Errorf("expected balance 1000, got %.2f", balance)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CurrencyInfo describes how amounts in a currency are displayed and rounded.
+type CurrencyInfo struct {
+	Symbol     string
+	MinorUnits int    // Number of decimal places, e.g. 2 for cents
+	Locale     Locale // Default locale used to format amounts
+}
+
+// Locale controls digit grouping and the decimal separator when formatting amounts.
+type Locale struct {
+	GroupSeparator   string
+	DecimalSeparator string
+}
+
+// Predefined locales
+var (
+	LocaleEnglish  = Locale{GroupSeparator: ",", DecimalSeparator: "."}
+	LocaleEuropean = Locale{GroupSeparator: ".", DecimalSeparator: ","}
+)
+
+// defaultCurrencies returns the settings for the supported seed currencies.
+func defaultCurrencies() map[string]CurrencyInfo {
+	return map[string]CurrencyInfo{
+		USD: {Symbol: "$", MinorUnits: 2, Locale: LocaleEnglish},
+		EUR: {Symbol: "€", MinorUnits: 2, Locale: LocaleEuropean},
+		GBP: {Symbol: "£", MinorUnits: 2, Locale: LocaleEnglish},
+	}
+}
+
+// FormatOption customizes how FormatAmount renders an amount.
+type FormatOption func(*Locale)
+
+// WithLocale formats the amount using the given locale instead of the currency's default.
+func WithLocale(locale Locale) FormatOption {
+	return func(l *Locale) {
+		*l = locale
+	}
+}
+
+// getCurrency retrieves the settings of a currency. Unknown currencies use their
+// code as the symbol and two minor units.
+func (b *BankService) getCurrency(currency string) CurrencyInfo {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	info, exists := b.currencies[currency]
+	if !exists {
+		return unknownCurrency(currency)
+	}
+	return info
+}
+
+// unknownCurrency returns the settings used for a currency that isn't registered.
+func unknownCurrency(currency string) CurrencyInfo {
+	return CurrencyInfo{Symbol: currency + " ", MinorUnits: 2, Locale: LocaleEnglish}
+}
+
+// maxMinorUnits is the most decimal places a currency can be configured with.
+const maxMinorUnits = 18
+
+// SetCurrencyPrecision sets the number of decimal places amounts in the currency are
+// rounded and formatted to, e.g. 8 for a crypto-like currency. Unknown currencies
+// are registered with their code as the symbol.
+func (b *BankService) SetCurrencyPrecision(currency string, decimals int) error {
+	if decimals < 0 || decimals > maxMinorUnits {
+		return ErrInvalidPrecision
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	info, exists := b.currencies[currency]
+	if !exists {
+		info = unknownCurrency(currency)
+	}
+	info.MinorUnits = decimals
+	b.currencies[currency] = info
+	fmt.Printf("Set precision for %s: %d decimals\n", currency, decimals)
+	return nil
+}
+
+// isKnownCurrency reports whether the currency is registered with the bank.
+func (b *BankService) isKnownCurrency(currency string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	_, known := b.currencies[currency]
+	return known
+}
+
+// FormatAmount renders an amount with the currency's symbol, digit grouping and
+// minor units, e.g. "$1,000.00" or "€1.000,00".
+func (b *BankService) FormatAmount(amount float64, currency string, opts ...FormatOption) string {
+	info := b.getCurrency(currency)
+	locale := info.Locale
+	for _, opt := range opts {
+		opt(&locale)
+	}
+
+	digits := strconv.FormatFloat(math.Abs(amount), 'f', info.MinorUnits, 64)
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+
+	var sb strings.Builder
+	if amount < 0 && strings.Trim(digits, "0.") != "" {
+		sb.WriteString("-")
+	}
+	sb.WriteString(info.Symbol)
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(locale.GroupSeparator)
+		}
+		sb.WriteRune(digit)
+	}
+	if fracPart != "" {
+		sb.WriteString(locale.DecimalSeparator)
+		sb.WriteString(fracPart)
+	}
+	return sb.String()
+}
+
+// GetFormattedBalance retrieves the balance of an account formatted for display in
+// the account's currency, e.g. "$1,000.00".
+func (b *BankService) GetFormattedBalance(userID, accountID int) (string, error) {
+	balance, currency, err := b.GetBalance(userID, accountID)
+	if err != nil {
+		return "", err
+	}
+	return b.FormatAmount(balance, currency), nil
+}
+
+// roundToMinorUnits rounds an amount half away from zero to the currency's minor units.
+func (b *BankService) roundToMinorUnits(amount float64, currency string) float64 {
+	scale := math.Pow10(b.getCurrency(currency).MinorUnits)
+	return math.Round(amount*scale) / scale
+}
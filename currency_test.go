@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestFormatAmount ensures each seed currency is formatted with its symbol and locale.
+func TestFormatAmount(t *testing.T) {
+	bank := NewBankService()
+
+	tests := []struct {
+		amount   float64
+		currency string
+		expected string
+	}{
+		{1000, USD, "$1,000.00"},
+		{1000, EUR, "€1.000,00"},
+		{1000, GBP, "£1,000.00"},
+		{1234567.891, USD, "$1,234,567.89"},
+		{-42.5, GBP, "-£42.50"},
+		{0.5, EUR, "€0,50"},
+	}
+
+	for _, tt := range tests {
+		if got := bank.FormatAmount(tt.amount, tt.currency); got != tt.expected {
+			t.Errorf("FormatAmount(%v, %s): expected %q, got %q", tt.amount, tt.currency, tt.expected, got)
+		}
+	}
+}
+
+// TestFormatAmountWithLocale ensures the locale can be overridden.
+func TestFormatAmountWithLocale(t *testing.T) {
+	bank := NewBankService()
+
+	if got := bank.FormatAmount(1000, EUR, WithLocale(LocaleEnglish)); got != "€1,000.00" {
+		t.Errorf("expected %q, got %q", "€1,000.00", got)
+	}
+}
+
+// TestGetFormattedBalance ensures the balance is formatted in the account's currency.
+func TestGetFormattedBalance(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1234.5, USD)
+
+	formatted, err := bank.GetFormattedBalance(1, accID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if formatted != "$1,234.50" {
+		t.Errorf("expected %q, got %q", "$1,234.50", formatted)
+	}
+
+	_, err = bank.GetFormattedBalance(2, accID)
+	if !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+}
+
+// TestSetCurrencyPrecision ensures a newly registered 8-decimal currency keeps its
+// precision through deposits, exchanges and formatting.
+func TestSetCurrencyPrecision(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	if err := bank.SetCurrencyPrecision("BTC", 8); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	usdID, _ := bank.CreateAccount(1, 100, USD)
+	btcID, _ := bank.CreateAccount(1, 0, "BTC")
+
+	bank.Deposit(1, btcID, 0.12345678)
+	if balance, _, _ := bank.GetBalance(1, btcID); balance != 0.12345678 {
+		t.Fatalf("expected balance 0.12345678, got %v", balance)
+	}
+
+	bank.SetExchangeRate(USD, "BTC", 0.0000152345678)
+	if err := bank.TransferWithExchange(1, usdID, btcID, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if formatted, _ := bank.GetFormattedBalance(1, btcID); formatted != "BTC 0.12498024" {
+		t.Errorf("expected %q, got %q", "BTC 0.12498024", formatted)
+	}
+}
+
+// TestSetCurrencyPrecisionInvalid ensures precisions outside 0 to 18 decimals are
+// rejected.
+func TestSetCurrencyPrecisionInvalid(t *testing.T) {
+	bank := NewBankService()
+
+	for _, decimals := range []int{-1, 19} {
+		if err := bank.SetCurrencyPrecision(USD, decimals); !errors.Is(err, ErrInvalidPrecision) {
+			t.Errorf("SetCurrencyPrecision(%d): expected ErrInvalidPrecision, got %v", decimals, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "time"
+
+// EventType identifies the kind of event emitted by the bank.
+type EventType string
+
+// Event types
+const (
+	EventAccountFrozen     EventType = "account_frozen"
+	EventAccountUnfrozen   EventType = "account_unfrozen"
+	EventFrozenDeposit     EventType = "frozen_deposit"      // Funds arrived into a frozen account
+	EventStaleExchangeRate EventType = "stale_exchange_rate" // Exchange used a rate past the staleness limit
+)
+
+// Event describes something notable that happened to an account.
+type Event struct {
+	Type      EventType
+	AccountID int
+	UserID    int // User who triggered the event
+	Amount    float64
+	Time      time.Time
+}
+
+// Subscribe registers a function that is called for every emitted event.
+// Subscribers are called synchronously after the triggering operation commits.
+func (b *BankService) Subscribe(fn func(Event)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// emit delivers an event to all subscribers. It must be called without holding
+// any bank or account locks.
+func (b *BankService) emit(event Event) {
+	b.mutex.Lock()
+	subscribers := make([]func(Event), len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.mutex.Unlock()
+
+	event.Time = b.clock()
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// OnSlowOperation registers a function that is called whenever a mutating operation,
+// including time spent waiting on locks, takes longer than threshold.
+func (b *BankService) OnSlowOperation(threshold time.Duration, fn func(op string, d time.Duration)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.slowThreshold = threshold
+	b.onSlow = fn
+}
+
+// observeDuration reports the operation to the slow-operation hook if it ran longer
+// than the threshold. It is meant to be deferred at method entry.
+func (b *BankService) observeDuration(op Operation, start time.Time) {
+	d := time.Since(start)
+
+	b.mutex.Lock()
+	threshold, fn := b.slowThreshold, b.onSlow
+	b.mutex.Unlock()
+
+	if fn != nil && d > threshold {
+		fn(string(op), d)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFreezeAccount ensures frozen accounts reject withdrawals.
+func TestFreezeAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Teller, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	if err := bank.FreezeAccount(2, accID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err := bank.Withdraw(1, accID, 100)
+	if !errors.Is(err, ErrAccountFrozen) {
+		t.Fatalf("expected ErrAccountFrozen, got %v", err)
+	}
+
+	if err := bank.UnfreezeAccount(2, accID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.Withdraw(1, accID, 100); err != nil {
+		t.Fatalf("expected no error after unfreeze, got %v", err)
+	}
+}
+
+// TestBankerBypassFreeze ensures Bankers only withdraw from frozen accounts when
+// explicitly allowed.
+func TestBankerBypassFreeze(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+	bank.FreezeAccount(2, accID)
+
+	if err := bank.Withdraw(2, accID, 100); !errors.Is(err, ErrAccountFrozen) {
+		t.Fatalf("expected ErrAccountFrozen by default, got %v", err)
+	}
+
+	bank.SetBankerBypassFreeze(true)
+	if err := bank.Withdraw(2, accID, 100); err != nil {
+		t.Fatalf("expected no error with bypass enabled, got %v", err)
+	}
+	if err := bank.Withdraw(1, accID, 100); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("expected ErrAccountFrozen for the customer, got %v", err)
+	}
+
+	bank.SetBankerBypassFreeze(false)
+	if err := bank.Withdraw(2, accID, 100); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("expected ErrAccountFrozen with bypass disabled, got %v", err)
+	}
+}
+
+// TestFreezeAccountUnauthorized ensures customers can't freeze accounts.
+func TestFreezeAccountUnauthorized(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	err := bank.FreezeAccount(1, accID)
+	if !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+}
+
+// TestDepositToFrozenAccount ensures deposits into frozen accounts succeed and emit an event.
+func TestDepositToFrozenAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	var events []Event
+	bank.Subscribe(func(e Event) {
+		if e.Type == EventFrozenDeposit {
+			events = append(events, e)
+		}
+	})
+
+	bank.FreezeAccount(2, accID)
+
+	if err := bank.Deposit(1, accID, 200); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 700 {
+		t.Errorf("expected balance 700, got %.2f", balance)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 frozen deposit event, got %d", len(events))
+	}
+	if events[0].AccountID != accID || events[0].Amount != 200 {
+		t.Errorf("expected event for account %d with amount 200, got %+v", accID, events[0])
+	}
+}
+
+// TestFreezeUserAccounts ensures all of a user's accounts are frozen in one call.
+func TestFreezeUserAccounts(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 200, EUR)
+	acc3, _ := bank.CreateAccount(1, 300, GBP)
+
+	count, err := bank.FreezeUserAccounts(2, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 accounts frozen, got %d", count)
+	}
+
+	for _, accID := range []int{acc1, acc2, acc3} {
+		err := bank.Withdraw(1, accID, 10)
+		if !errors.Is(err, ErrAccountFrozen) {
+			t.Errorf("expected ErrAccountFrozen for account %d, got %v", accID, err)
+		}
+	}
+}
+
+// TestFreezeUserAccountsUnauthorized ensures customers can't freeze another user's accounts.
+func TestFreezeUserAccountsUnauthorized(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	bank.CreateAccount(1, 100, USD)
+
+	_, err := bank.FreezeUserAccounts(2, 1)
+	if !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+}
+
+// TestOnSlowOperation ensures the hook fires when an operation waits on a held lock.
+func TestOnSlowOperation(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	var slowOps []string
+	bank.OnSlowOperation(10*time.Millisecond, func(op string, d time.Duration) {
+		slowOps = append(slowOps, op)
+	})
+
+	account := bank.accounts[accID]
+	account.mutex.Lock()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		account.mutex.Unlock()
+	}()
+
+	if err := bank.Deposit(1, accID, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(slowOps) != 1 || slowOps[0] != string(OpDeposit) {
+		t.Errorf("expected one slow deposit, got %v", slowOps)
+	}
+}
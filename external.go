@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// WithdrawToExternal debits an account for a payout that leaves the bank, such as a
+// wire transfer, recording the external reference in the account's history. It
+// applies the same checks as Withdraw but never uses backup funds. It returns the
+// ID of the payout transaction.
+func (b *BankService) WithdrawToExternal(userID, accountID int, amount float64, reference string) (int64, error) {
+	defer b.observeDuration(OpWithdraw, time.Now())
+
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, accountID); err != nil {
+		return 0, err
+	}
+	if err := b.checkTransactionLimit(userID, OpWithdraw, amount); err != nil {
+		return 0, err
+	}
+	if err := b.throttle(accountID); err != nil {
+		return 0, err
+	}
+
+	account := b.lookupAccount(accountID)
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return 0, err
+	}
+	defer account.mutex.Unlock()
+
+	if account.closed {
+		return 0, accountError(accountID, ErrAccountClosed)
+	}
+	if account.frozen {
+		return 0, accountError(accountID, ErrAccountFrozen)
+	}
+	if account.available()+account.overdraftLimit < amount {
+		return 0, ErrInsufficientBalance
+	}
+
+	account.balance -= amount
+	tx := b.record(accountID, account, Transaction{Type: TxExternalPayout, Amount: amount, Counterparty: noCounterparty, Reference: reference})
+	fmt.Printf("User %d paid out %.2f from account %d to external reference %s\n", userID, amount, accountID, reference)
+	return tx.ID, nil
+}
+
+// DepositFromExternal credits an account with funds arriving from outside the bank,
+// such as an incoming wire, recording the source reference in the account's
+// history. It returns the ID of the credit transaction.
+func (b *BankService) DepositFromExternal(userID, accountID int, amount float64, reference string) (int64, error) {
+	tx, err := b.deposit(userID, accountID, Transaction{Type: TxExternalCredit, Amount: amount, Counterparty: noCounterparty, Reference: reference})
+	if err != nil {
+		return 0, err
+	}
+	return tx.ID, nil
+}
+
+// Refund credits an account with a reversal of an earlier external payout from it,
+// referencing the original transaction. Refunds of a payout may not add up to more
+// than the payout itself. It returns the ID of the refund transaction.
+func (b *BankService) Refund(userID, accountID int, originalTxID int64, amount float64) (int64, error) {
+	defer b.observeDuration(OpDeposit, time.Now())
+
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return 0, err
+	}
+	if err := b.throttle(accountID); err != nil {
+		return 0, err
+	}
+
+	account := b.lookupAccount(accountID)
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return 0, err
+	}
+	defer account.mutex.Unlock()
+
+	i := slices.IndexFunc(account.history, func(tx Transaction) bool {
+		return tx.ID == originalTxID && tx.Type == TxExternalPayout
+	})
+	if i < 0 {
+		return 0, accountError(accountID, ErrTransactionNotFound)
+	}
+	if account.refunded[originalTxID]+amount > account.history[i].Amount {
+		return 0, accountError(accountID, ErrRefundExceedsOriginal)
+	}
+	if account.closed {
+		return 0, accountError(accountID, ErrAccountClosed)
+	}
+	if err := account.checkCredit(accountID, amount); err != nil {
+		return 0, err
+	}
+
+	if account.refunded == nil {
+		account.refunded = make(map[int64]float64)
+	}
+	account.refunded[originalTxID] += amount
+	account.balance += amount
+	tx := b.record(accountID, account, Transaction{Type: TxRefund, Amount: amount, Counterparty: noCounterparty, Reference: strconv.FormatInt(originalTxID, 10)})
+	fmt.Printf("User %d refunded %.2f of transaction %d to account %d\n", userID, amount, originalTxID, accountID)
+	return tx.ID, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestWithdrawToExternal ensures payouts debit the account and record the reference.
+func TestWithdrawToExternal(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+
+	txID, err := bank.WithdrawToExternal(1, accID, 400, "WIRE-001")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 600 {
+		t.Errorf("expected balance 600, got %.2f", balance)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, accID)
+	last := history[len(history)-1]
+	if last.ID != txID || last.Type != TxExternalPayout || last.Reference != "WIRE-001" {
+		t.Errorf("expected external payout %d with reference WIRE-001, got %+v", txID, last)
+	}
+}
+
+// TestWithdrawToExternalChecks ensures payouts apply the same checks as withdrawals.
+func TestWithdrawToExternalChecks(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	bank.CreateUser(3, Banker, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	if _, err := bank.WithdrawToExternal(1, accID, 200, "WIRE-002"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+	if _, err := bank.WithdrawToExternal(2, accID, 50, "WIRE-003"); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+
+	bank.FreezeAccount(3, accID)
+	if _, err := bank.WithdrawToExternal(1, accID, 50, "WIRE-004"); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("expected ErrAccountFrozen, got %v", err)
+	}
+}
+
+// TestDepositFromExternal ensures incoming credits are recorded with their reference.
+func TestDepositFromExternal(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	txID, err := bank.DepositFromExternal(1, accID, 250, "WIRE-IN-42")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 350 {
+		t.Errorf("expected balance 350, got %.2f", balance)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, accID)
+	last := history[len(history)-1]
+	if last.ID != txID || last.Type != TxExternalCredit || last.Reference != "WIRE-IN-42" {
+		t.Errorf("expected external credit %d with reference WIRE-IN-42, got %+v", txID, last)
+	}
+}
+
+// TestDepositFromExternalMaxBalance ensures incoming credits respect the balance cap.
+func TestDepositFromExternalMaxBalance(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetMaxBalance(accID, 300)
+
+	_, err := bank.DepositFromExternal(1, accID, 250, "WIRE-IN-43")
+	if !errors.Is(err, ErrMaxBalanceExceeded) {
+		t.Fatalf("expected ErrMaxBalanceExceeded, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 100 {
+		t.Errorf("expected balance 100, got %.2f", balance)
+	}
+}
+
+// TestRefund ensures refunds of a payout can't add up to more than the payout.
+func TestRefund(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	payoutID, _ := bank.WithdrawToExternal(1, accID, 100, "WIRE-1")
+
+	if _, err := bank.Refund(1, accID, payoutID, 40); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	refundID, err := bank.Refund(1, accID, payoutID, 50)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := bank.Refund(1, accID, payoutID, 20); !errors.Is(err, ErrRefundExceedsOriginal) {
+		t.Fatalf("expected ErrRefundExceedsOriginal, got %v", err)
+	}
+	if _, err := bank.Refund(1, accID, refundID, 5); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 490 {
+		t.Errorf("expected balance 490, got %.2f", balance)
+	}
+	history, _ := bank.GetTransactionHistory(1, accID)
+	if last := history[len(history)-1]; last.Type != TxRefund || last.Reference != fmt.Sprint(payoutID) {
+		t.Errorf("expected refund referencing %d, got %s referencing %s", payoutID, last.Type, last.Reference)
+	}
+}
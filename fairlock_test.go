@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFairLocking ensures contended transfers run in the order they arrived, so no
+// transfer is overtaken by later ones.
+func TestFairLocking(t *testing.T) {
+	bank := NewBankService()
+	bank.SetFairLocking(true)
+	bank.CreateUser(1, Customer, false)
+	fromID, _ := bank.CreateAccount(1, 1000, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+
+	// Hold the source account's queue so the transfers line up behind it.
+	from := bank.lookupAccount(fromID)
+	from.queue.lock()
+
+	const transfers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < transfers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bank.TransferWithMemo(1, fromID, toID, 1, fmt.Sprint(i))
+		}()
+		waitForWaiters(t, &from.queue, i+1)
+	}
+	from.queue.unlock()
+	wg.Wait()
+
+	history, _ := bank.GetTransactionHistory(1, toID)
+	if len(history) != transfers {
+		t.Fatalf("expected %d transfers, got %d", transfers, len(history))
+	}
+	for i, tx := range history {
+		if tx.Memo != fmt.Sprint(i) {
+			t.Fatalf("expected transfer %d to run in position %d, got transfer %s", i, i, tx.Memo)
+		}
+	}
+}
+
+// waitForWaiters waits until n callers are queued on the lock.
+func waitForWaiters(t *testing.T, l *fifoLock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mutex.Lock()
+		queued := len(l.waiters)
+		l.mutex.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued transfers, got %d", n, queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestFairLockingCoversAllMovers ensures every operation moving funds between
+// accounts waits in the queue rather than overtaking it.
+func TestFairLockingCoversAllMovers(t *testing.T) {
+	bank := NewBankService()
+	bank.SetFairLocking(true)
+	bank.CreateUser(1, Customer, false)
+	fromID, _ := bank.CreateAccount(1, 1000, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+	eurID, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetExchangeRate(USD, EUR, 0.85)
+	holdID, _ := bank.PlaceHold(1, fromID, 10)
+
+	movers := map[string]func() error{
+		"ExchangeCurrency": func() error { return bank.ExchangeCurrency(1, fromID, eurID, 10) },
+		"TransferCurrency": func() error { return bank.TransferCurrency(1, fromID, toID, 10, USD) },
+		"TransferVia":      func() error { return bank.TransferVia(1, []int{fromID, toID}, 10) },
+		"CaptureHold":      func() error { return bank.CaptureHoldToAccount(holdID, toID, 10) },
+	}
+	from := bank.lookupAccount(fromID)
+	for name, move := range movers {
+		from.queue.lock()
+		done := make(chan error)
+		go func() { done <- move() }()
+		waitForWaiters(t, &from.queue, 1)
+		from.queue.unlock()
+
+		if err := <-done; err != nil {
+			t.Errorf("%s: expected no error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// dormancyFee is the fee charged to accounts in a currency after a period of inactivity.
+type dormancyFee struct {
+	fee   float64
+	after time.Duration
+}
+
+// SetDormancyFee configures a fee charged by ApplyDormancyFees to accounts in the
+// currency that have been idle for longer than after. A zero fee disables it.
+func (b *BankService) SetDormancyFee(currency string, fee float64, after time.Duration) error {
+	if fee < 0 || after <= 0 {
+		return ErrInvalidAmount
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.dormancyFees[currency] = dormancyFee{fee: fee, after: after}
+	fmt.Printf("Set dormancy fee for %s: %.2f after %s\n", currency, fee, after)
+	return nil
+}
+
+// ApplyDormancyFees charges the dormancy fee to every account idle beyond its
+// currency's threshold and collects it as fee income. An account is charged
+// at most once per threshold period and never below a zero balance. It returns the
+// number of accounts charged.
+func (b *BankService) ApplyDormancyFees(now time.Time) int {
+	b.mutex.Lock()
+	fees := make(map[string]dormancyFee, len(b.dormancyFees))
+	for currency, fee := range b.dormancyFees {
+		fees[currency] = fee
+	}
+	b.mutex.Unlock()
+
+	charged := 0
+	for _, accountID := range b.accountIDs() {
+		account, err := b.getAccount(accountID)
+		if err != nil {
+			continue
+		}
+
+		account.mutex.Lock()
+		amount := b.chargeDormancyFee(accountID, account, fees[account.currency], now)
+		currency := account.currency
+		account.mutex.Unlock()
+
+		if amount > 0 {
+			b.collectFee(accountID, currency, amount)
+			charged++
+		}
+	}
+	return charged
+}
+
+// chargeDormancyFee debits the dormancy fee from an idle account and returns the
+// amount charged. The caller must hold the account lock.
+func (b *BankService) chargeDormancyFee(accountID int, account *Account, fee dormancyFee, now time.Time) float64 {
+	if fee.fee == 0 {
+		return 0
+	}
+
+	// Idle time counts from the later of the last activity and the last fee.
+	since := account.lastActivity
+	if account.lastDormancyFee.After(since) {
+		since = account.lastDormancyFee
+	}
+	if now.Sub(since) <= fee.after {
+		return 0
+	}
+	account.lastDormancyFee = now
+
+	amount := math.Min(fee.fee, math.Max(account.available(), 0))
+	if amount == 0 {
+		return 0
+	}
+
+	account.balance -= amount
+	b.recordTransaction(accountID, account, TxDormancyFee, amount, noCounterparty)
+	fmt.Printf("Charged %.2f dormancy fee to account %d\n", amount, accountID)
+	return amount
+}
+
+// SetFeeAccount makes the bank credit fees collected in a currency to the account
+// instead of the fee pool. The account must be open and hold the currency.
+func (b *BankService) SetFeeAccount(currency string, accountID int) error {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.RLock()
+	closed, accountCurrency := account.closed, account.currency
+	account.mutex.RUnlock()
+
+	if closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	if accountCurrency != currency {
+		return accountError(accountID, ErrCurrencyMismatch)
+	}
+
+	b.mutex.Lock()
+	b.feeAccounts[currency] = accountID
+	b.mutex.Unlock()
+	fmt.Printf("Set fee account for %s: %d\n", currency, accountID)
+	return nil
+}
+
+// collectFee credits a fee charged to payerID to the currency's fee account. Without
+// a fee account, or if it has since been closed, the fee goes to the fee pool.
+func (b *BankService) collectFee(payerID int, currency string, amount float64) {
+	b.mutex.Lock()
+	feeID, hasAccount := b.feeAccounts[currency]
+	b.mutex.Unlock()
+
+	if hasAccount {
+		account := b.lookupAccount(feeID)
+		account.mutex.Lock()
+		if !account.closed {
+			account.balance += amount
+			b.recordTransaction(feeID, account, TxFeeIncome, amount, payerID)
+			account.mutex.Unlock()
+			return
+		}
+		account.mutex.Unlock()
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.feePool[currency] += amount
+}
+
+// FeePool returns the total fees collected in a currency that weren't credited to
+// a fee account.
+func (b *BankService) FeePool(currency string) float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.feePool[currency]
+}
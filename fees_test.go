@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestApplyDormancyFees ensures idle accounts are charged once and the fee is collected.
+func TestApplyDormancyFees(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	idle, _ := bank.CreateAccount(1, 100, USD)
+	nearlyEmpty, _ := bank.CreateAccount(1, 3, USD)
+	active, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetDormancyFee(USD, 5, 90*day)
+
+	clock.Advance(80 * day)
+	bank.Deposit(1, active, 10)
+	clock.Advance(20 * day)
+
+	if charged := bank.ApplyDormancyFees(clock.Now()); charged != 2 {
+		t.Fatalf("expected 2 accounts charged, got %d", charged)
+	}
+
+	idleBalance, _, _ := bank.GetBalance(1, idle)
+	nearlyEmptyBalance, _, _ := bank.GetBalance(1, nearlyEmpty)
+	activeBalance, _, _ := bank.GetBalance(1, active)
+	if idleBalance != 95 || nearlyEmptyBalance != 0 || activeBalance != 110 {
+		t.Errorf("expected balances 95, 0 and 110, got %.2f, %.2f and %.2f", idleBalance, nearlyEmptyBalance, activeBalance)
+	}
+	if pool := bank.FeePool(USD); pool != 8 {
+		t.Errorf("expected fee pool 8, got %.2f", pool)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, idle)
+	if last := history[len(history)-1]; last.Type != TxDormancyFee {
+		t.Errorf("expected last transaction to be %s, got %s", TxDormancyFee, last.Type)
+	}
+
+	// A second sweep in the same period charges nothing.
+	if charged := bank.ApplyDormancyFees(clock.Now()); charged != 0 {
+		t.Errorf("expected no accounts charged, got %d", charged)
+	}
+}
+
+// TestSetFeeAccount ensures collected fees are credited to the fee account instead
+// of the fee pool.
+func TestSetFeeAccount(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+
+	idle, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetDormancyFee(USD, 5, 90*day)
+	clock.Advance(80 * day)
+
+	feeAccount, _ := bank.CreateAccount(2, 0, USD)
+	eurAccount, _ := bank.CreateAccount(2, 0, EUR)
+	if err := bank.SetFeeAccount(USD, eurAccount); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	if err := bank.SetFeeAccount(USD, feeAccount); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	clock.Advance(20 * day)
+	if charged := bank.ApplyDormancyFees(clock.Now()); charged != 1 {
+		t.Fatalf("expected 1 account charged, got %d", charged)
+	}
+
+	if balance, _, _ := bank.GetBalance(2, feeAccount); balance != 5 {
+		t.Errorf("expected fee account balance 5, got %.2f", balance)
+	}
+	if pool := bank.FeePool(USD); pool != 0 {
+		t.Errorf("expected empty fee pool, got %.2f", pool)
+	}
+
+	history, _ := bank.GetTransactionHistory(2, feeAccount)
+	if last := history[len(history)-1]; last.Type != TxFeeIncome || last.Counterparty != idle {
+		t.Errorf("expected %s from account %d, got %s from account %d", TxFeeIncome, idle, last.Type, last.Counterparty)
+	}
+}
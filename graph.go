@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Edge aggregates the transfers from one account to another.
+type Edge struct {
+	FromAccount int
+	ToAccount   int
+	Total       float64 // Sum of the transferred amounts in the source currency
+	Count       int
+}
+
+// TransferEdges aggregates transfers made in [from, to) by source and destination
+// account, for analysing money movement. The edges come from one consistent read of
+// every account's history and are sorted by source, then destination account. A zero
+// from or to leaves that end of the window open.
+func (b *BankService) TransferEdges(from, to time.Time) []Edge {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	accountIDs := make([]int, 0, len(b.accounts))
+	for accountID := range b.accounts {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Ints(accountIDs)
+
+	window := TransactionFilter{Types: []TransactionType{TxTransferOut}, From: from, To: to}
+	var edges []Edge
+	for _, accountID := range accountIDs {
+		account := b.accounts[accountID]
+		account.mutex.RLock()
+		defer account.mutex.RUnlock()
+
+		index := make(map[int]int) // Position in edges by destination account
+		for i := range account.history {
+			tx := &account.history[i]
+			if !window.matches(tx) {
+				continue
+			}
+			pos, exists := index[tx.Counterparty]
+			if !exists {
+				pos = len(edges)
+				index[tx.Counterparty] = pos
+				edges = append(edges, Edge{FromAccount: accountID, ToAccount: tx.Counterparty})
+			}
+			edges[pos].Total += tx.Amount
+			edges[pos].Count++
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].FromAccount != edges[j].FromAccount {
+			return edges[i].FromAccount < edges[j].FromAccount
+		}
+		return edges[i].ToAccount < edges[j].ToAccount
+	})
+	return edges
+}
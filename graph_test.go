@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestTransferEdges ensures transfers within the window are aggregated by source and
+// destination account.
+func TestTransferEdges(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	a, _ := bank.CreateAccount(1, 1000, USD)
+	b, _ := bank.CreateAccount(1, 1000, USD)
+	c, _ := bank.CreateAccount(2, 1000, USD)
+
+	bank.Transfer(a, c, 500) // Before the window
+	clock.Advance(day)
+	start := clock.Now()
+
+	bank.Transfer(a, c, 100)
+	bank.Transfer(a, c, 50)
+	bank.Transfer(c, a, 30)
+	bank.Transfer(b, c, 20)
+	bank.Transfer(a, b, 10)
+	bank.Withdraw(1, a, 5) // Not a transfer
+
+	clock.Advance(day)
+	end := clock.Now()
+	bank.Transfer(b, c, 70) // At the exclusive end of the window
+
+	expected := []Edge{
+		{FromAccount: a, ToAccount: b, Total: 10, Count: 1},
+		{FromAccount: a, ToAccount: c, Total: 150, Count: 2},
+		{FromAccount: b, ToAccount: c, Total: 20, Count: 1},
+		{FromAccount: c, ToAccount: a, Total: 30, Count: 1},
+	}
+	if edges := bank.TransferEdges(start, end); !slices.Equal(edges, expected) {
+		t.Errorf("expected %+v, got %+v", expected, edges)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// TransactionType identifies the kind of balance change a transaction records.
+type TransactionType string
+
+// Transaction types
+const (
+	TxDeposit     TransactionType = "deposit"
+	TxWithdrawal  TransactionType = "withdrawal"
+	TxTransferIn  TransactionType = "transfer_in"
+	TxTransferOut TransactionType = "transfer_out"
+	TxExchangeIn  TransactionType = "exchange_in"
+	TxExchangeOut TransactionType = "exchange_out"
+
+	TxExternalPayout TransactionType = "external_payout" // Funds sent outside the bank
+	TxExternalCredit TransactionType = "external_credit" // Funds received from outside the bank
+	TxRefund         TransactionType = "refund"          // Reversal of an external payout
+
+	TxInterest          TransactionType = "interest"
+	TxOverdraftInterest TransactionType = "overdraft_interest"
+	TxDormancyFee       TransactionType = "dormancy_fee"
+	TxFeeIncome         TransactionType = "fee_income" // Fee credited to the bank's fee account
+
+	TxHoldCapture TransactionType = "hold_capture" // Held funds debited
+	TxHoldExpired TransactionType = "hold_expired" // Hold released by expiry, balance unchanged
+)
+
+// isSystemGenerated reports whether the bank, rather than a customer, initiated
+// transactions of this type. Such transactions don't count as account activity.
+func (t TransactionType) isSystemGenerated() bool {
+	return t == TxInterest || t == TxOverdraftInterest || t == TxDormancyFee || t == TxFeeIncome || t == TxHoldExpired
+}
+
+// isOutflow reports whether transactions of this type take funds out of an account.
+func (t TransactionType) isOutflow() bool {
+	switch t {
+	case TxWithdrawal, TxTransferOut, TxExchangeOut, TxExternalPayout, TxOverdraftInterest, TxDormancyFee, TxHoldCapture:
+		return true
+	}
+	return false
+}
+
+// noCounterparty marks a transaction that doesn't involve another account.
+const noCounterparty = -1
+
+// maxMemoLength is the maximum number of characters in a transaction memo.
+const maxMemoLength = 140
+
+// Transaction is a single entry in an account's history. Each entry is chained to
+// the previous one by hash, so any modification of the history is detectable.
+type Transaction struct {
+	ID           int64
+	AccountID    int
+	Type         TransactionType
+	Amount       float64
+	Currency     string  // Sub-balance currency in a multi-currency account, empty for the account's own
+	Balance      float64 // Balance in the transaction's currency afterwards
+	Counterparty int     // Other account involved, or -1 if none
+	Memo         string  // Free-form description, e.g. "invoice #123"
+	Reference    string  // External reference, e.g. a wire ID
+	Category     string  // Budgeting category, e.g. "groceries"
+	Rate         float64 // Exchange rate applied by an exchange, zero otherwise
+	Time         time.Time
+	PrevHash     string
+	Hash         string
+}
+
+// computeHash returns the SHA-256 of the previous hash and the transaction's fields.
+func (t *Transaction) computeHash() string {
+	data := fmt.Sprintf("%s|%d|%d|%s|%.8f|%s|%.8f|%d|%q|%q|%q|%v|%d",
+		t.PrevHash, t.ID, t.AccountID, t.Type, t.Amount, t.Currency, t.Balance, t.Counterparty, t.Memo, t.Reference, t.Category, t.Rate, t.Time.UnixNano())
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordTransaction appends a transaction to the account's history, chaining it to
+// the previous entry. The caller must hold the account lock.
+func (b *BankService) recordTransaction(accountID int, account *Account, txType TransactionType, amount float64, counterparty int) Transaction {
+	return b.record(accountID, account, Transaction{Type: txType, Amount: amount, Counterparty: counterparty})
+}
+
+// record completes tx with its ID, account, resulting balance, time and hashes, and
+// appends it to the account's history. The caller must hold the account lock.
+func (b *BankService) record(accountID int, account *Account, tx Transaction) Transaction {
+	tx.ID = b.nextTxID.Add(1)
+	tx.AccountID = accountID
+	tx.Balance = account.balanceIn(tx.Currency)
+	tx.Time = b.clock()
+	tx.PrevHash = account.archivedHash
+	if n := len(account.history); n > 0 {
+		tx.PrevHash = account.history[n-1].Hash
+	}
+	tx.Hash = tx.computeHash()
+
+	account.history = append(account.history, tx)
+	b.archiveHistory(account)
+	account.commit(accountID, tx.Type, tx.Currency == "")
+	if !tx.Type.isSystemGenerated() {
+		account.lastActivity = tx.Time
+	}
+	if account.crossedLowBalance(&tx) {
+		// Notify asynchronously, since looking up the handler takes the bank lock.
+		go b.notify(lowBalanceNotification(accountID, tx.Balance, account.lowBalance))
+	}
+	return tx
+}
+
+// SetHistoryLimit makes each account retain only its n most recent transactions,
+// passing older ones to the OnArchive callback before dropping them. Zero keeps the
+// full history. The limit applies as transactions are recorded.
+func (b *BankService) SetHistoryLimit(n int) error {
+	if n < 0 {
+		return ErrInvalidAmount
+	}
+	b.historyLimit.Store(int64(n))
+	return nil
+}
+
+// OnArchive registers a function that is called with every transaction evicted from
+// an account's history by the history limit, oldest first, so it can be persisted
+// elsewhere. It is called while the account is locked and must not call back into
+// the bank.
+func (b *BankService) OnArchive(fn func(Transaction)) {
+	b.onArchive.Store(&fn)
+}
+
+// archiveHistory evicts transactions beyond the history limit, oldest first. The
+// caller must hold the account lock.
+func (b *BankService) archiveHistory(account *Account) {
+	limit := int(b.historyLimit.Load())
+	if limit == 0 || len(account.history) <= limit {
+		return
+	}
+
+	evicted := account.history[:len(account.history)-limit]
+	if fn := b.onArchive.Load(); fn != nil && *fn != nil {
+		for _, tx := range evicted {
+			(*fn)(tx)
+		}
+	}
+	account.archivedHash = evicted[len(evicted)-1].Hash
+	account.history = account.history[len(evicted):]
+}
+
+// GetTransactionHistory retrieves a copy of the account's transaction history.
+func (b *BankService) GetTransactionHistory(userID, accountID int) ([]Transaction, error) {
+	if err := b.CheckViewPermissions(userID, accountID); err != nil {
+		return nil, err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	history := make([]Transaction, len(account.history))
+	copy(history, account.history)
+	return history, nil
+}
+
+// VerifyAuditChain recomputes the account's hash chain and reports whether any
+// transaction has been tampered with.
+func (b *BankService) VerifyAuditChain(accountID int) error {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	prevHash := account.archivedHash
+	for _, tx := range account.history {
+		if tx.PrevHash != prevHash || tx.computeHash() != tx.Hash {
+			return ErrAuditChainBroken
+		}
+		prevHash = tx.Hash
+	}
+	return nil
+}
+
+// TransactionFilter selects transactions in QueryTransactions. Zero-valued fields
+// don't restrict the results.
+type TransactionFilter struct {
+	Types     []TransactionType // Match any of these types
+	MinAmount float64
+	MaxAmount float64
+	From      time.Time // Inclusive
+	To        time.Time // Exclusive
+	Offset    int       // Number of matching transactions to skip
+	Limit     int       // Maximum number of transactions to return
+}
+
+// matches reports whether the transaction satisfies the filter's criteria.
+func (f *TransactionFilter) matches(tx *Transaction) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, tx.Type) {
+		return false
+	}
+	if f.MinAmount > 0 && tx.Amount < f.MinAmount {
+		return false
+	}
+	if f.MaxAmount > 0 && tx.Amount > f.MaxAmount {
+		return false
+	}
+	if !f.From.IsZero() && tx.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !tx.Time.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// QueryTransactions retrieves the account's transactions matching the filter, oldest
+// first, with offset-based paging.
+func (b *BankService) QueryTransactions(userID, accountID int, filter TransactionFilter) ([]Transaction, error) {
+	if err := b.CheckViewPermissions(userID, accountID); err != nil {
+		return nil, err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	var result []Transaction
+	skipped := 0
+	for i := range account.history {
+		tx := &account.history[i]
+		if !filter.matches(tx) {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		result = append(result, *tx)
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestTransactionHistory ensures operations are recorded in account history.
+func TestTransactionHistory(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	bank.Deposit(1, acc1, 200)
+	bank.Withdraw(1, acc1, 100)
+	bank.Transfer(acc1, acc2, 300)
+
+	history, err := bank.GetTransactionHistory(1, acc1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []TransactionType{TxDeposit, TxDeposit, TxWithdrawal, TxTransferOut}
+	if len(history) != len(expected) {
+		t.Fatalf("expected %d transactions, got %d", len(expected), len(history))
+	}
+	for i, tx := range history {
+		if tx.Type != expected[i] {
+			t.Errorf("expected transaction %d to be %s, got %s", i, expected[i], tx.Type)
+		}
+	}
+	if last := history[len(history)-1]; last.Balance != 800 || last.Counterparty != acc2 {
+		t.Errorf("expected balance 800 and counterparty %d, got %.2f and %d", acc2, last.Balance, last.Counterparty)
+	}
+}
+
+// TestVerifyAuditChain ensures an untouched history verifies successfully.
+func TestVerifyAuditChain(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+
+	bank.Deposit(1, accID, 200)
+	bank.Withdraw(1, accID, 100)
+
+	if err := bank.VerifyAuditChain(accID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// TestVerifyAuditChainTampered ensures modifying a middle entry breaks the chain.
+func TestVerifyAuditChainTampered(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+
+	bank.Deposit(1, accID, 200)
+	bank.Withdraw(1, accID, 100)
+
+	bank.accounts[accID].history[1].Amount = 2000
+
+	err := bank.VerifyAuditChain(accID)
+	if !errors.Is(err, ErrAuditChainBroken) {
+		t.Fatalf("expected ErrAuditChainBroken, got %v", err)
+	}
+}
+
+// TestTransferWithMemo ensures the memo is recorded on both sides of a transfer.
+func TestTransferWithMemo(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(2, 0, USD)
+
+	txID, err := bank.TransferWithMemo(1, acc1, acc2, 250, "invoice #123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fromHistory, _ := bank.GetTransactionHistory(1, acc1)
+	toHistory, _ := bank.GetTransactionHistory(2, acc2)
+
+	debit := fromHistory[len(fromHistory)-1]
+	credit := toHistory[len(toHistory)-1]
+	if debit.ID != txID || debit.Memo != "invoice #123" {
+		t.Errorf("expected debit %d with memo, got %+v", txID, debit)
+	}
+	if credit.Type != TxTransferIn || credit.Memo != "invoice #123" {
+		t.Errorf("expected credit with memo, got %+v", credit)
+	}
+}
+
+// TestTransferWithMemoTooLong ensures overly long memos are rejected.
+func TestTransferWithMemoTooLong(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	_, err := bank.TransferWithMemo(1, acc1, acc2, 250, strings.Repeat("a", maxMemoLength+1))
+	if !errors.Is(err, ErrMemoTooLong) {
+		t.Fatalf("expected ErrMemoTooLong, got %v", err)
+	}
+}
+
+// TestQueryTransactionsByType ensures transactions can be filtered by type and amount.
+func TestQueryTransactionsByType(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+
+	bank.Withdraw(1, accID, 100)
+	bank.Deposit(1, accID, 50)
+	bank.Withdraw(1, accID, 300)
+
+	withdrawals, err := bank.QueryTransactions(1, accID, TransactionFilter{Types: []TransactionType{TxWithdrawal}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(withdrawals) != 2 || withdrawals[0].Amount != 100 || withdrawals[1].Amount != 300 {
+		t.Errorf("expected withdrawals of 100 and 300, got %+v", withdrawals)
+	}
+
+	large, _ := bank.QueryTransactions(1, accID, TransactionFilter{MinAmount: 200, MaxAmount: 500})
+	if len(large) != 1 || large[0].Amount != 300 {
+		t.Errorf("expected a single transaction of 300, got %+v", large)
+	}
+}
+
+// TestQueryTransactionsPaging ensures offset and limit page through the history.
+func TestQueryTransactionsPaging(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	for i := 1; i <= 5; i++ {
+		bank.Deposit(1, accID, float64(i))
+	}
+
+	var amounts []float64
+	for offset := 0; ; offset += 2 {
+		page, err := bank.QueryTransactions(1, accID, TransactionFilter{Offset: offset, Limit: 2})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, tx := range page {
+			amounts = append(amounts, tx.Amount)
+		}
+	}
+
+	expected := []float64{1, 2, 3, 4, 5}
+	if !slices.Equal(amounts, expected) {
+		t.Errorf("expected amounts %v, got %v", expected, amounts)
+	}
+}
+
+// TestSetHistoryLimit ensures only the most recent transactions are retained, older
+// ones are archived in order, and the audit chain still verifies.
+func TestSetHistoryLimit(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	var archived []float64
+	bank.OnArchive(func(tx Transaction) {
+		archived = append(archived, tx.Amount)
+	})
+	if err := bank.SetHistoryLimit(3); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for amount := 1.0; amount <= 5; amount++ {
+		bank.Deposit(1, accID, amount)
+	}
+
+	if expected := []float64{1, 2}; !slices.Equal(archived, expected) {
+		t.Errorf("expected archived amounts %v, got %v", expected, archived)
+	}
+	history, _ := bank.GetTransactionHistory(1, accID)
+	var retained []float64
+	for _, tx := range history {
+		retained = append(retained, tx.Amount)
+	}
+	if expected := []float64{3, 4, 5}; !slices.Equal(retained, expected) {
+		t.Errorf("expected retained amounts %v, got %v", expected, retained)
+	}
+	if err := bank.VerifyAuditChain(accID); err != nil {
+		t.Errorf("expected the truncated chain to verify, got %v", err)
+	}
+}
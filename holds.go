@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// hold is an amount reserved on an account, for example by a card authorization.
+type hold struct {
+	amount  float64
+	placed  time.Time
+	expires bool // False for holds reserving a scheduled transfer, which last until it runs
+}
+
+// PlaceHold reserves amount on the account so it can't be debited elsewhere, and
+// returns the hold's ID. The funds stay in the balance until the hold is captured.
+func (b *BankService) PlaceHold(userID, accountID int, amount float64) (int64, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, accountID); err != nil {
+		return 0, err
+	}
+	if err := b.throttle(accountID); err != nil {
+		return 0, err
+	}
+
+	holdID := b.registerHold(accountID)
+	account := b.lookupAccount(accountID)
+	err := b.reserve(accountID, account, holdID, amount, true)
+	if err != nil {
+		b.unregisterHold(holdID)
+		return 0, err
+	}
+
+	fmt.Printf("User %d placed hold %d of %.2f on account %d\n", userID, holdID, amount, accountID)
+	return holdID, nil
+}
+
+// reserve adds a hold of amount to the account, which ProcessExpiredHolds releases
+// once it is too old if expires is set.
+func (b *BankService) reserve(accountID int, account *Account, holdID int64, amount float64, expires bool) error {
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return err
+	}
+	defer account.mutex.Unlock()
+
+	if account.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	if account.frozen {
+		return accountError(accountID, ErrAccountFrozen)
+	}
+	if account.available() < amount {
+		return ErrInsufficientBalance
+	}
+
+	if account.activeHolds == nil {
+		account.activeHolds = make(map[int64]hold)
+	}
+	account.activeHolds[holdID] = hold{amount: amount, placed: b.clock(), expires: expires}
+	account.held += amount
+	return nil
+}
+
+// registerHold allocates a hold ID and records which account it belongs to.
+func (b *BankService) registerHold(accountID int) int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.nextHoldID++
+	b.holdAccounts[b.nextHoldID] = accountID
+	return b.nextHoldID
+}
+
+// unregisterHold forgets a hold that has been released, captured or never placed.
+func (b *BankService) unregisterHold(holdID int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.holdAccounts, holdID)
+}
+
+// lockHold locks the account a hold was placed on and returns it with the hold.
+// On success the caller must unlock the account.
+func (b *BankService) lockHold(holdID int64) (int, *Account, hold, error) {
+	b.mutex.Lock()
+	accountID, exists := b.holdAccounts[holdID]
+	b.mutex.Unlock()
+	if !exists {
+		return 0, nil, hold{}, ErrHoldNotFound
+	}
+
+	account := b.lookupAccount(accountID)
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return 0, nil, hold{}, err
+	}
+	h, exists := account.activeHolds[holdID]
+	if !exists {
+		account.mutex.Unlock()
+		return 0, nil, hold{}, ErrHoldNotFound
+	}
+	return accountID, account, h, nil
+}
+
+// removeHold deletes a hold from its account. The caller must hold the account lock.
+func (a *Account) removeHold(holdID int64) {
+	a.held -= a.activeHolds[holdID].amount
+	delete(a.activeHolds, holdID)
+}
+
+// ReleaseHold removes a hold without debiting the account, making the funds
+// available again.
+func (b *BankService) ReleaseHold(holdID int64) error {
+	accountID, account, _, err := b.lockHold(holdID)
+	if err != nil {
+		return err
+	}
+	account.removeHold(holdID)
+	account.mutex.Unlock()
+
+	b.unregisterHold(holdID)
+	fmt.Printf("Released hold %d on account %d\n", holdID, accountID)
+	return nil
+}
+
+// CaptureHold debits up to the held amount from the account and releases the rest
+// of the hold.
+func (b *BankService) CaptureHold(holdID int64, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	accountID, account, h, err := b.lockHold(holdID)
+	if err != nil {
+		return err
+	}
+	if amount > h.amount {
+		account.mutex.Unlock()
+		return ErrInvalidAmount
+	}
+	if account.frozen {
+		account.mutex.Unlock()
+		return accountError(accountID, ErrAccountFrozen)
+	}
+
+	account.removeHold(holdID)
+	account.balance -= amount
+	b.recordTransaction(accountID, account, TxHoldCapture, amount, noCounterparty)
+	account.mutex.Unlock()
+
+	b.unregisterHold(holdID)
+	fmt.Printf("Captured %.2f of hold %d on account %d\n", amount, holdID, accountID)
+	return nil
+}
+
+// CaptureHoldToAccount captures up to the held amount like CaptureHold, but moves the
+// funds to destID, e.g. to settle a card authorization to a merchant's account. The
+// destination must share the held account's currency.
+func (b *BankService) CaptureHoldToAccount(holdID int64, destID int, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	b.mutex.Lock()
+	accountID, exists := b.holdAccounts[holdID]
+	b.mutex.Unlock()
+	if !exists {
+		return ErrHoldNotFound
+	}
+	if err := b.throttle(accountID); err != nil {
+		return err
+	}
+	return b.transferHeld(holdID, destID, amount)
+}
+
+// SetHoldExpiry sets how long holds last before ProcessExpiredHolds releases them.
+// Zero means holds never expire. Funds reserved by ScheduleTransfer are held until
+// the transfer runs or is canceled, whatever the expiry.
+func (b *BankService) SetHoldExpiry(d time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.holdExpiry = d
+}
+
+// ProcessExpiredHolds releases every hold placed longer ago than the hold expiry,
+// recording the expiry in the account's history, and returns how many expired.
+func (b *BankService) ProcessExpiredHolds(now time.Time) int {
+	b.mutex.Lock()
+	expiry := b.holdExpiry
+	b.mutex.Unlock()
+	if expiry <= 0 {
+		return 0
+	}
+
+	var expired []int64
+	for _, accountID := range b.accountIDs() {
+		account, err := b.getAccount(accountID)
+		if err != nil {
+			continue
+		}
+
+		account.mutex.Lock()
+		for holdID, h := range account.activeHolds {
+			if !h.expires || now.Sub(h.placed) <= expiry {
+				continue
+			}
+			account.removeHold(holdID)
+			b.record(accountID, account, Transaction{Type: TxHoldExpired, Amount: h.amount, Counterparty: noCounterparty, Reference: fmt.Sprint(holdID)})
+			expired = append(expired, holdID)
+			fmt.Printf("Hold %d of %.2f on account %d expired\n", holdID, h.amount, accountID)
+		}
+		account.mutex.Unlock()
+	}
+
+	for _, holdID := range expired {
+		b.unregisterHold(holdID)
+	}
+	return len(expired)
+}
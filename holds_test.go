@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestHolds ensures held funds can't be debited until released, and captures debit them.
+func TestHolds(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	holdID, err := bank.PlaceHold(1, accID, 80)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.Withdraw(1, accID, 50); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected held funds to be unavailable, got %v", err)
+	}
+
+	if err := bank.ReleaseHold(holdID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.ReleaseHold(holdID); !errors.Is(err, ErrHoldNotFound) {
+		t.Fatalf("expected ErrHoldNotFound, got %v", err)
+	}
+	if err := bank.Withdraw(1, accID, 50); err != nil {
+		t.Fatalf("expected released funds to be available, got %v", err)
+	}
+
+	holdID, _ = bank.PlaceHold(1, accID, 40)
+	if err := bank.CaptureHold(holdID, 30); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 20 {
+		t.Errorf("expected balance 20, got %.2f", balance)
+	}
+	if err := bank.Withdraw(1, accID, 20); err != nil {
+		t.Errorf("expected the uncaptured remainder to be released, got %v", err)
+	}
+}
+
+// TestCaptureHoldToAccount ensures captured funds move to the destination account
+// and the uncaptured remainder is released.
+func TestCaptureHoldToAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	cardID, _ := bank.CreateAccount(1, 100, USD)
+	merchantID, _ := bank.CreateAccount(2, 0, USD)
+	euroID, _ := bank.CreateAccount(2, 0, EUR)
+
+	holdID, _ := bank.PlaceHold(1, cardID, 60)
+	if err := bank.CaptureHoldToAccount(holdID, euroID, 45); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	if err := bank.CaptureHoldToAccount(holdID, merchantID, 45); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if balance, _, _ := bank.GetBalance(1, cardID); balance != 55 {
+		t.Errorf("expected card balance 55, got %.2f", balance)
+	}
+	if balance, _, _ := bank.GetBalance(2, merchantID); balance != 45 {
+		t.Errorf("expected merchant balance 45, got %.2f", balance)
+	}
+	if err := bank.Withdraw(1, cardID, 55); err != nil {
+		t.Errorf("expected the uncaptured remainder to be released, got %v", err)
+	}
+	if err := bank.CaptureHoldToAccount(holdID, merchantID, 10); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("expected ErrHoldNotFound, got %v", err)
+	}
+}
+
+// TestProcessExpiredHolds ensures holds past the expiry are released and recorded.
+func TestProcessExpiredHolds(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.SetHoldExpiry(7 * day)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	bank.PlaceHold(1, accID, 60)
+	clock.Advance(5 * day)
+	recent, _ := bank.PlaceHold(1, accID, 30)
+	clock.Advance(3 * day)
+
+	if expired := bank.ProcessExpiredHolds(clock.Now()); expired != 1 {
+		t.Fatalf("expected 1 expired hold, got %d", expired)
+	}
+	if err := bank.Withdraw(1, accID, 70); err != nil {
+		t.Errorf("expected expired hold to be released, got %v", err)
+	}
+	if err := bank.ReleaseHold(recent); err != nil {
+		t.Errorf("expected recent hold to remain, got %v", err)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, accID)
+	if expired := history[1]; expired.Type != TxHoldExpired || expired.Amount != 60 || expired.Balance != 100 {
+		t.Errorf("expected hold expiry of 60 at balance 100, got %s of %.2f at %.2f", expired.Type, expired.Amount, expired.Balance)
+	}
+}
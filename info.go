@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLabelLength is the maximum number of characters in an account label.
+const maxLabelLength = 64
+
+// AccountInfo is a read-only view of an account.
+type AccountInfo struct {
+	ID       int
+	OwnerID  int
+	Balance  float64
+	Currency string
+	Frozen   bool
+	Closed   bool
+	Label    string
+
+	LastActivity time.Time // Last customer-initiated transaction
+	Version      int64     // Changes with every transaction, see WithdrawIfVersion
+
+	UnpostedInterest float64 // Interest included in Balance but not yet posted
+}
+
+// info returns a view of the account. The caller must hold the account lock.
+func (a *Account) info(accountID int) AccountInfo {
+	return AccountInfo{
+		ID:       accountID,
+		OwnerID:  a.ownerID,
+		Balance:  a.balance,
+		Currency: a.currency,
+		Frozen:   a.frozen,
+		Closed:   a.closed,
+		Label:    a.label,
+
+		LastActivity: a.lastActivity,
+		Version:      a.version,
+
+		UnpostedInterest: a.unposted,
+	}
+}
+
+// GetAccountInfo retrieves a read-only view of an account.
+func (b *BankService) GetAccountInfo(userID, accountID int) (AccountInfo, error) {
+	if err := b.CheckViewPermissions(userID, accountID); err != nil {
+		return AccountInfo{}, err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	return account.info(accountID), nil
+}
+
+// AccountOwner returns the ID of the user who owns the account. The owner, Bankers
+// and Tellers can look it up.
+func (b *BankService) AccountOwner(requesterID, accountID int) (int, error) {
+	if err := b.CheckViewPermissions(requesterID, accountID); err != nil {
+		return 0, err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	return account.ownerID, nil
+}
+
+// SetAccountLabel gives an account a free-form name such as "Rent" or "Savings".
+// Labels have no effect on balances.
+func (b *BankService) SetAccountLabel(userID, accountID int, label string) error {
+	if utf8.RuneCountInString(label) > maxLabelLength {
+		return ErrLabelTooLong
+	}
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	account.label = label
+	fmt.Printf("User %d labeled account %d %q\n", userID, accountID, label)
+	return nil
+}
+
+// SetExternalRef maps an identifier from an external system, such as an IBAN or a
+// customer number, to the account, replacing its previous one. Each reference can
+// belong to only one account. An empty ref removes the account's reference.
+func (b *BankService) SetExternalRef(accountID int, ref string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.accounts[accountID]; !exists {
+		return accountError(accountID, ErrAccountNotExist)
+	}
+	if owner, exists := b.externalRefs[ref]; exists && owner != accountID {
+		return accountError(accountID, ErrExternalRefInUse)
+	}
+
+	delete(b.externalRefs, b.accountRefs[accountID])
+	if ref == "" {
+		delete(b.accountRefs, accountID)
+	} else {
+		b.externalRefs[ref] = accountID
+		b.accountRefs[accountID] = ref
+	}
+	fmt.Printf("Set external reference of account %d to %q\n", accountID, ref)
+	return nil
+}
+
+// AccountByExternalRef returns the ID of the account with the external reference.
+func (b *BankService) AccountByExternalRef(ref string) (int, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	accountID, exists := b.externalRefs[ref]
+	return accountID, exists
+}
+
+// DormantAccounts returns the IDs, in ascending order, of accounts without any
+// customer activity for longer than threshold.
+func (b *BankService) DormantAccounts(threshold time.Duration) []int {
+	now := b.clock()
+
+	var dormant []int
+	for _, accountID := range b.accountIDs() {
+		account, err := b.getAccount(accountID)
+		if err != nil {
+			continue
+		}
+		account.mutex.RLock()
+		idle := now.Sub(account.lastActivity)
+		account.mutex.RUnlock()
+
+		if idle > threshold {
+			dormant = append(dormant, accountID)
+		}
+	}
+	return dormant
+}
+
+// UserInfo is a read-only view of a user.
+type UserInfo struct {
+	ID           int
+	Role         Role
+	Active       bool
+	AccountCount int
+}
+
+// ListUsers returns a view of every user, sorted by user ID.
+func (b *BankService) ListUsers() []UserInfo {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	users := make([]UserInfo, 0, len(b.users))
+	for _, user := range b.users {
+		users = append(users, UserInfo{
+			ID:           user.ID,
+			Role:         user.Role,
+			Active:       !user.Deactivated,
+			AccountCount: len(user.Accounts),
+		})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users
+}
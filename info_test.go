@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestGetAccountInfo ensures the account view reflects the account's state.
+func TestGetAccountInfo(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, EUR)
+
+	info, err := bank.GetAccountInfo(1, accID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := AccountInfo{ID: accID, OwnerID: 1, Balance: 500, Currency: EUR, LastActivity: clock.Now(), Version: 1}
+	if info != expected {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+}
+
+// TestAccountOwner ensures the owner can be looked up by the owner and staff only.
+func TestAccountOwner(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	bank.CreateUser(3, Teller, false)
+	bank.CreateUser(4, Banker, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	for _, requesterID := range []int{1, 3, 4} {
+		owner, err := bank.AccountOwner(requesterID, accID)
+		if err != nil || owner != 1 {
+			t.Errorf("requester %d: expected owner 1, got %d, %v", requesterID, owner, err)
+		}
+	}
+	if _, err := bank.AccountOwner(2, accID); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+	if _, err := bank.AccountOwner(4, 99); !errors.Is(err, ErrAccountNotExist) {
+		t.Errorf("expected ErrAccountNotExist, got %v", err)
+	}
+}
+
+// TestSetAccountLabel ensures labels can be set and read back.
+func TestSetAccountLabel(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	if err := bank.SetAccountLabel(1, accID, "Rent"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	info, _ := bank.GetAccountInfo(1, accID)
+	if info.Label != "Rent" {
+		t.Errorf("expected label %q, got %q", "Rent", info.Label)
+	}
+}
+
+// TestSetAccountLabelTooLong ensures overly long labels are rejected.
+func TestSetAccountLabelTooLong(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	err := bank.SetAccountLabel(1, accID, strings.Repeat("a", maxLabelLength+1))
+	if !errors.Is(err, ErrLabelTooLong) {
+		t.Fatalf("expected ErrLabelTooLong, got %v", err)
+	}
+}
+
+// TestSetExternalRef ensures accounts can be resolved by a unique external reference.
+func TestSetExternalRef(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+	otherID, _ := bank.CreateAccount(1, 500, USD)
+
+	const iban = "DE89370400440532013000"
+	if err := bank.SetExternalRef(accID, iban); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id, ok := bank.AccountByExternalRef(iban); !ok || id != accID {
+		t.Errorf("expected account %d, got %d (found %v)", accID, id, ok)
+	}
+
+	if err := bank.SetExternalRef(otherID, iban); !errors.Is(err, ErrExternalRefInUse) {
+		t.Errorf("expected ErrExternalRefInUse, got %v", err)
+	}
+
+	// Replacing the reference frees the old one.
+	bank.SetExternalRef(accID, "CUST-42")
+	if _, ok := bank.AccountByExternalRef(iban); ok {
+		t.Errorf("expected %s to be unassigned", iban)
+	}
+	if err := bank.SetExternalRef(otherID, iban); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+// TestDormantAccounts ensures accounts idle past the threshold are listed.
+func TestDormantAccounts(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	idle, _ := bank.CreateAccount(1, 100, USD)
+	active, _ := bank.CreateAccount(1, 100, USD)
+
+	clock.Advance(60 * day)
+	bank.Deposit(1, active, 10)
+	clock.Advance(40 * day)
+
+	dormant := bank.DormantAccounts(90 * day)
+	if len(dormant) != 1 || dormant[0] != idle {
+		t.Errorf("expected only account %d to be dormant, got %v", idle, dormant)
+	}
+
+	info, _ := bank.GetAccountInfo(1, active)
+	if !info.LastActivity.Equal(clock.Now().Add(-40 * day)) {
+		t.Errorf("expected last activity 40 days ago, got %v", info.LastActivity)
+	}
+}
+
+// TestListUsers ensures all users are listed in ID order with their details.
+func TestListUsers(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(3, Teller, false)
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+
+	bank.CreateAccount(1, 100, USD)
+	bank.CreateAccount(1, 100, EUR)
+	bank.DeactivateUser(2, 1)
+
+	expected := []UserInfo{
+		{ID: 1, Role: Customer, Active: false, AccountCount: 2},
+		{ID: 2, Role: Banker, Active: true, AccountCount: 0},
+		{ID: 3, Role: Teller, Active: true, AccountCount: 0},
+	}
+	if users := bank.ListUsers(); !slices.Equal(users, expected) {
+		t.Errorf("expected %+v, got %+v", expected, users)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// day is the accrual period for daily rates.
+const day = 24 * time.Hour
+
+// SetOverdraftLimit allows the account's balance to go negative by up to limit
+// on withdrawals.
+func (b *BankService) SetOverdraftLimit(accountID int, limit float64) error {
+	if limit < 0 {
+		return ErrInvalidAmount
+	}
+
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if account.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	account.overdraftLimit = limit
+	fmt.Printf("Set overdraft limit for account %d: %.2f\n", accountID, limit)
+	return nil
+}
+
+// SetOverdraftRate sets the daily interest rate charged on a negative balance.
+// Interest is charged from the time the rate is set.
+func (b *BankService) SetOverdraftRate(accountID int, dailyRate float64) error {
+	if dailyRate < 0 {
+		return ErrInvalidAmount
+	}
+
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+	now := b.clock()
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if account.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	b.accrueInterest(accountID, account, now)
+	account.overdraftRate = dailyRate
+	account.lastAccrual = now
+	fmt.Printf("Set overdraft rate for account %d: %.4f per day\n", accountID, dailyRate)
+	return nil
+}
+
+// daysPerYear converts annual interest rates to daily ones.
+const daysPerYear = 365
+
+// Frequency is how often accrued interest is compounded into an account's balance.
+type Frequency int
+
+// Compounding frequencies
+const (
+	Daily Frequency = iota
+	Monthly
+	Annually
+)
+
+// next returns the compounding boundary that follows t.
+func (f Frequency) next(t time.Time) time.Time {
+	switch f {
+	case Monthly:
+		return t.AddDate(0, 1, 0)
+	case Annually:
+		return t.AddDate(1, 0, 0)
+	}
+	return t.Add(day)
+}
+
+// SetInterestRate sets the annual interest rate paid on a positive balance.
+// Interest is paid from the time the rate is set.
+func (b *BankService) SetInterestRate(accountID int, annualRate float64) error {
+	if annualRate < 0 {
+		return ErrInvalidAmount
+	}
+
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+	now := b.clock()
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if account.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	b.accrueInterest(accountID, account, now)
+	account.interestRate = annualRate
+	account.lastAccrual = now
+	fmt.Printf("Set interest rate for account %d: %.4f per year\n", accountID, annualRate)
+	return nil
+}
+
+// SetCompoundingFrequency sets how often interest is compounded into the account's
+// balance. Interest accrued under the previous frequency is settled first.
+func (b *BankService) SetCompoundingFrequency(accountID int, freq Frequency) error {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+	now := b.clock()
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	b.accrueInterest(accountID, account, now)
+	account.compounding = freq
+	return nil
+}
+
+// AccrueInterest compounds interest into every account for each compounding period
+// completed since its last accrual: positive balances earn the interest rate and
+// negative balances are charged the overdraft rate.
+func (b *BankService) AccrueInterest() {
+	now := b.clock()
+
+	for _, accountID := range b.accountIDs() {
+		account, err := b.getAccount(accountID)
+		if err != nil {
+			continue
+		}
+		account.mutex.Lock()
+		b.accrueInterest(accountID, account, now)
+		account.mutex.Unlock()
+	}
+}
+
+// accrueInterest compounds interest for each compounding period completed up to now.
+// The caller must hold the account lock.
+func (b *BankService) accrueInterest(accountID int, account *Account, now time.Time) {
+	for {
+		boundary := account.compounding.next(account.lastAccrual)
+		if boundary.After(now) {
+			return
+		}
+
+		days := float64(boundary.Sub(account.lastAccrual) / day)
+		account.lastAccrual = boundary
+
+		interest := periodInterest(account.balance, account.interestRate, account.overdraftRate, days)
+		if interest == 0 {
+			continue
+		}
+		account.balance += interest
+
+		if account.deferPosting {
+			account.unposted += interest
+			cause := TxInterest
+			if interest < 0 {
+				cause = TxOverdraftInterest
+			}
+			account.commit(accountID, cause, true)
+			continue
+		}
+		if interest > 0 {
+			b.recordTransaction(accountID, account, TxInterest, interest, noCounterparty)
+			fmt.Printf("Paid %.2f interest to account %d\n", interest, accountID)
+		} else {
+			b.recordTransaction(accountID, account, TxOverdraftInterest, -interest, noCounterparty)
+			fmt.Printf("Charged %.2f overdraft interest to account %d\n", -interest, accountID)
+		}
+	}
+}
+
+// ProjectBalance returns the balance the account would reach by until if interest
+// were accrued with its current rates and compounding and no other transactions
+// occurred. The account is left unchanged.
+func (b *BankService) ProjectBalance(accountID int, until time.Time) (float64, error) {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	balance, last := account.balance, account.lastAccrual
+	for {
+		boundary := account.compounding.next(last)
+		if boundary.After(until) {
+			return balance, nil
+		}
+		days := float64(boundary.Sub(last) / day)
+		last = boundary
+		balance += periodInterest(balance, account.interestRate, account.overdraftRate, days)
+	}
+}
+
+// SetDeferredInterestPosting sets whether interest accrued on the account is held
+// back from its history until PostInterest is called, e.g. to post interest at month
+// end. Accrued interest is added to the balance either way. Turning deferral off
+// posts any interest still pending.
+func (b *BankService) SetDeferredInterestPosting(accountID int, deferred bool) error {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if !deferred {
+		b.postInterest(accountID, account)
+	}
+	account.deferPosting = deferred
+	return nil
+}
+
+// PostInterest accrues the account's interest up to at and records the interest
+// accrued since the last posting as a single transaction.
+func (b *BankService) PostInterest(accountID int, at time.Time) error {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	b.accrueInterest(accountID, account, at)
+	b.postInterest(accountID, account)
+	return nil
+}
+
+// postInterest records the account's unposted interest. The caller must hold the
+// account lock.
+func (b *BankService) postInterest(accountID int, account *Account) {
+	interest := account.unposted
+	account.unposted = 0
+
+	switch {
+	case interest > 0:
+		b.recordTransaction(accountID, account, TxInterest, interest, noCounterparty)
+		fmt.Printf("Posted %.2f interest to account %d\n", interest, accountID)
+	case interest < 0:
+		b.recordTransaction(accountID, account, TxOverdraftInterest, -interest, noCounterparty)
+		fmt.Printf("Posted %.2f overdraft interest to account %d\n", -interest, accountID)
+	}
+}
+
+// periodInterest returns the signed interest for a compounding period of the given
+// number of days: positive on a positive balance, negative on an overdrawn one.
+func periodInterest(balance, annualRate, overdraftDailyRate, days float64) float64 {
+	if balance > 0 {
+		return balance * annualRate * days / daysPerYear
+	}
+	return balance * overdraftDailyRate * days
+}
+
+// accountIDs returns the IDs of all accounts in ascending order.
+func (b *BankService) accountIDs() []int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	ids := make([]int, 0, len(b.accounts))
+	for id := range b.accounts {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
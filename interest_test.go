@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced time source for tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// TestOverdraftWithdrawal ensures withdrawals can use the overdraft limit but not exceed it.
+func TestOverdraftWithdrawal(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetOverdraftLimit(accID, 200)
+
+	if err := bank.Withdraw(1, accID, 250); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != -150 {
+		t.Errorf("expected balance -150, got %.2f", balance)
+	}
+
+	err := bank.Withdraw(1, accID, 100)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+}
+
+// TestOverdraftTransfer ensures transfers can use the overdraft limit but not exceed it.
+func TestOverdraftTransfer(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	fromID, _ := bank.CreateAccount(1, 100, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetOverdraftLimit(fromID, 200)
+
+	if err := bank.CanTransfer(fromID, toID, 250); err != nil {
+		t.Errorf("expected CanTransfer to allow overdraft, got %v", err)
+	}
+	if err := bank.Transfer(fromID, toID, 250); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fromBalance, _, _ := bank.GetBalance(1, fromID)
+	toBalance, _, _ := bank.GetBalance(1, toID)
+	if fromBalance != -150 || toBalance != 250 {
+		t.Errorf("expected balances -150 and 250, got %.2f and %.2f", fromBalance, toBalance)
+	}
+
+	err := bank.Transfer(fromID, toID, 100)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+}
+
+// TestOverdraftInterest ensures interest is charged on negative balances as the clock advances.
+func TestOverdraftInterest(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetOverdraftLimit(accID, 1000)
+	bank.SetOverdraftRate(accID, 0.01)
+	bank.Withdraw(1, accID, 100)
+
+	clock.Advance(2 * day)
+	bank.AccrueInterest()
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	expected := -100 * 1.01 * 1.01
+	if math.Abs(balance-expected) > 1e-9 {
+		t.Errorf("expected balance %.4f, got %.4f", expected, balance)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, accID)
+	last := history[len(history)-1]
+	if last.Type != TxOverdraftInterest {
+		t.Errorf("expected last transaction to be %s, got %s", TxOverdraftInterest, last.Type)
+	}
+}
+
+// TestOverdraftInterestPositiveBalance ensures no interest is charged on positive balances.
+func TestOverdraftInterestPositiveBalance(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetOverdraftRate(accID, 0.01)
+
+	clock.Advance(10 * day)
+	bank.AccrueInterest()
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 100 {
+		t.Errorf("expected balance 100, got %.2f", balance)
+	}
+}
+
+// TestCompoundingFrequency ensures daily and monthly compounding produce different balances.
+func TestCompoundingFrequency(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	dailyID, _ := bank.CreateAccount(1, 1000, USD)
+	monthlyID, _ := bank.CreateAccount(1, 1000, USD)
+	bank.SetInterestRate(dailyID, 0.05)
+	bank.SetInterestRate(monthlyID, 0.05)
+	bank.SetCompoundingFrequency(monthlyID, Monthly)
+
+	end := clock.Now().AddDate(1, 0, 0)
+	days := float64(end.Sub(clock.Now()) / day)
+	clock.Advance(end.Sub(clock.Now()))
+	bank.AccrueInterest()
+
+	daily, _, _ := bank.GetBalance(1, dailyID)
+	monthly, _, _ := bank.GetBalance(1, monthlyID)
+
+	expectedDaily := 1000 * math.Pow(1+0.05/daysPerYear, days)
+	if math.Abs(daily-expectedDaily) > 1e-6 {
+		t.Errorf("expected daily balance %.6f, got %.6f", expectedDaily, daily)
+	}
+
+	simple := 1000 * (1 + 0.05*days/daysPerYear)
+	if !(daily > monthly && monthly > simple) {
+		t.Errorf("expected daily %.6f > monthly %.6f > simple %.6f", daily, monthly, simple)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, monthlyID)
+	postings := 0
+	for _, tx := range history {
+		if tx.Type == TxInterest {
+			postings++
+		}
+	}
+	if postings != 12 {
+		t.Errorf("expected 12 monthly interest postings, got %d", postings)
+	}
+}
+
+// TestPostInterest ensures deferred interest accrues into the balance daily but is
+// only recorded when posted, as a single transaction for the accrued sum.
+func TestPostInterest(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+	bank.SetInterestRate(accID, 0.05)
+	if err := bank.SetDeferredInterestPosting(accID, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	monthEnd := clock.Now().AddDate(0, 1, 0)
+	for clock.Now().Before(monthEnd.Add(-day)) {
+		clock.Advance(day)
+		bank.AccrueInterest()
+	}
+
+	info, _ := bank.GetAccountInfo(1, accID)
+	if info.Balance <= 1000 || math.Abs(info.UnpostedInterest-(info.Balance-1000)) > 1e-9 {
+		t.Fatalf("expected accrued interest in the balance, got %+v", info)
+	}
+	history, _ := bank.GetTransactionHistory(1, accID)
+	if len(history) != 1 {
+		t.Fatalf("expected no interest transactions before posting, got %d entries", len(history))
+	}
+
+	clock.Advance(day)
+	if err := bank.PostInterest(accID, monthEnd); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	history, _ = bank.GetTransactionHistory(1, accID)
+	posted := history[len(history)-1]
+	if len(history) != 2 || posted.Type != TxInterest {
+		t.Fatalf("expected a single interest posting, got %+v", history)
+	}
+	if math.Abs(posted.Amount-(balance-1000)) > 1e-9 {
+		t.Errorf("expected posted interest %.6f, got %.6f", balance-1000, posted.Amount)
+	}
+	if info, _ := bank.GetAccountInfo(1, accID); info.UnpostedInterest != 0 {
+		t.Errorf("expected no unposted interest, got %.6f", info.UnpostedInterest)
+	}
+}
+
+// TestProjectBalance ensures a one-year projection matches actual accrual and leaves
+// the account unchanged.
+func TestProjectBalance(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+	bank.SetInterestRate(accID, 0.05)
+	bank.SetCompoundingFrequency(accID, Monthly)
+
+	end := clock.Now().AddDate(1, 0, 0)
+	projected, err := bank.ProjectBalance(accID, end)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 1000 {
+		t.Fatalf("expected the projection to leave the balance at 1000, got %.2f", balance)
+	}
+
+	clock.Advance(end.Sub(clock.Now()))
+	bank.AccrueInterest()
+	if actual, _, _ := bank.GetBalance(1, accID); projected != actual {
+		t.Errorf("expected projected balance %.6f to match accrued %.6f", projected, actual)
+	}
+}
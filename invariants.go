@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SetStrictInvariants enables internal consistency checks that panic on violation.
+// It is meant for tests, to catch regressions in concurrency-sensitive code.
+func (b *BankService) SetStrictInvariants(strict bool) {
+	b.strictInvariants.Store(strict)
+}
+
+// assertCurrency panics in strict mode if the account's currency differs from the
+// one observed when the operation was validated. The caller must hold the account lock.
+func (b *BankService) assertCurrency(accountID int, account *Account, validated string) {
+	if b.strictInvariants.Load() && account.currency != validated {
+		panic(fmt.Sprintf("invariant violated: account %d currency changed from %s to %s", accountID, validated, account.currency))
+	}
+}
+
+// CheckIntegrity verifies that every account's owner exists, every account listed
+// under a user exists and is owned by them, and no balance is NaN or infinite. It
+// returns the first violation found.
+func (b *BankService) CheckIntegrity() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	accountIDs := make([]int, 0, len(b.accounts))
+	for id := range b.accounts {
+		accountIDs = append(accountIDs, id)
+	}
+	sort.Ints(accountIDs)
+
+	for _, accountID := range accountIDs {
+		account := b.accounts[accountID]
+		account.mutex.RLock()
+		ownerID, balance := account.ownerID, account.balance
+		account.mutex.RUnlock()
+
+		if _, exists := b.users[ownerID]; !exists {
+			return &BankError{UserID: ownerID, AccountID: accountID, Err: ErrOrphanedAccount}
+		}
+		if math.IsNaN(balance) || math.IsInf(balance, 0) {
+			return accountError(accountID, ErrInvalidBalance)
+		}
+	}
+
+	userIDs := make([]int, 0, len(b.users))
+	for id := range b.users {
+		userIDs = append(userIDs, id)
+	}
+	sort.Ints(userIDs)
+
+	for _, userID := range userIDs {
+		for _, accountID := range b.users[userID].Accounts {
+			account, exists := b.accounts[accountID]
+			if !exists {
+				return &BankError{UserID: userID, AccountID: accountID, Err: ErrAccountNotExist}
+			}
+			account.mutex.RLock()
+			ownerID := account.ownerID
+			account.mutex.RUnlock()
+			if ownerID != userID {
+				return &BankError{UserID: userID, AccountID: accountID, Err: ErrOwnerMismatch}
+			}
+		}
+	}
+	return nil
+}
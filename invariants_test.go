@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestStrictInvariants ensures normal operations pass strict checks and a changed
+// currency is caught.
+func TestStrictInvariants(t *testing.T) {
+	bank := NewBankService()
+	bank.SetStrictInvariants(true)
+	bank.CreateUser(1, Customer, false)
+
+	usdID, _ := bank.CreateAccount(1, 500, USD)
+	usdID2, _ := bank.CreateAccount(1, 0, USD)
+	eurID, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetExchangeRate(USD, EUR, 0.9)
+
+	if err := bank.Transfer(usdID, usdID2, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.ExchangeCurrency(1, usdID, eurID, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic on a changed currency")
+		}
+	}()
+	bank.assertCurrency(eurID, bank.accounts[eurID], USD)
+}
+
+// corrupt applies fn to the account's internal state to simulate a bug.
+func corrupt(bank *BankService, accountID int, fn func(*Account)) {
+	account := bank.accounts[accountID]
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+	fn(account)
+}
+
+// TestCheckIntegrity ensures each kind of corrupted state is detected.
+func TestCheckIntegrity(t *testing.T) {
+	tests := []struct {
+		name     string
+		corrupt  func(bank *BankService, accID int)
+		expected error
+	}{
+		{"orphaned account", func(bank *BankService, accID int) {
+			corrupt(bank, accID, func(a *Account) { a.ownerID = 99 })
+		}, ErrOrphanedAccount},
+		{"non-finite balance", func(bank *BankService, accID int) {
+			corrupt(bank, accID, func(a *Account) { a.balance = math.NaN() })
+		}, ErrInvalidBalance},
+		{"missing account", func(bank *BankService, accID int) {
+			delete(bank.accounts, accID)
+		}, ErrAccountNotExist},
+		{"owner mismatch", func(bank *BankService, accID int) {
+			corrupt(bank, accID, func(a *Account) { a.ownerID = 2 })
+		}, ErrOwnerMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank := NewBankService()
+			bank.CreateUser(1, Customer, false)
+			bank.CreateUser(2, Customer, false)
+			accID, _ := bank.CreateAccount(1, 100, USD)
+
+			if err := bank.CheckIntegrity(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			tt.corrupt(bank, accID)
+			if err := bank.CheckIntegrity(); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
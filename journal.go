@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// journalPhase marks whether a journal record precedes or follows a command.
+type journalPhase string
+
+// Journal phases
+const (
+	phaseIntent   journalPhase = "intent" // Written before the command runs
+	phaseComplete journalPhase = "complete"
+)
+
+// journalRecord is a single line of the journal, encoded as JSON.
+type journalRecord struct {
+	Seq     int64        `json:"seq"`
+	Phase   journalPhase `json:"phase"`
+	Command *Command     `json:"command,omitempty"` // Set on intent records
+}
+
+// SetJournal makes Execute write an intent record to w before running each mutating
+// command, and a completion record once it has succeeded. Replaying the journal
+// with Replay rebuilds the bank's state. A nil writer disables journaling.
+//
+// Only commands run through Execute are journaled. Calling Deposit, Transfer or any
+// other method directly bypasses the journal, so a journaled bank must be driven
+// through Execute alone for Replay to reproduce it. That includes exchange rates,
+// which are set with an OpSetRate command.
+//
+// Once a write fails, the journal no longer matches the bank, so Execute refuses
+// further mutating commands with that error until SetJournal is called again.
+func (b *BankService) SetJournal(w io.Writer) {
+	b.journalMutex.Lock()
+	defer b.journalMutex.Unlock()
+
+	b.journal = w
+	b.journalErr = nil
+}
+
+// executeJournaled runs a mutating command, journaling it if a journal is set. The
+// command doesn't run if its intent can't be journaled.
+func (b *BankService) executeJournaled(cmd Command) (Result, error) {
+	seq, err := b.writeJournal(journalRecord{Phase: phaseIntent, Command: &cmd})
+	if err != nil {
+		return Result{}, err
+	}
+
+	result, err := b.execute(cmd)
+	if err != nil || seq == 0 {
+		return result, err
+	}
+
+	// The command has taken effect, so it succeeded even if its completion can't be
+	// journaled. writeJournal then refuses further commands.
+	if _, err := b.writeJournal(journalRecord{Seq: seq, Phase: phaseComplete}); err != nil {
+		fmt.Printf("Journal lost the completion of command %d: %v\n", seq, err)
+	}
+	return result, nil
+}
+
+// writeJournal appends a record to the journal, numbering intent records, and
+// returns the record's sequence number. It returns zero if no journal is set.
+func (b *BankService) writeJournal(record journalRecord) (int64, error) {
+	b.journalMutex.Lock()
+	defer b.journalMutex.Unlock()
+
+	if b.journal == nil {
+		return 0, nil
+	}
+	if b.journalErr != nil {
+		return 0, b.journalErr
+	}
+	if record.Phase == phaseIntent {
+		b.journalSeq++
+		record.Seq = b.journalSeq
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := b.journal.Write(append(line, '\n')); err != nil {
+		b.journalErr = fmt.Errorf("writing journal: %w", err)
+		return 0, b.journalErr
+	}
+	return record.Seq, nil
+}
+
+// Replay applies the completed commands of a journal, in the order they completed,
+// to rebuild the state of the bank that wrote it. Commands without a completion
+// record, such as those interrupted by a crash, are skipped. Replayed commands
+// aren't journaled again.
+func (b *BankService) Replay(r io.Reader) error {
+	intents := make(map[int64]Command)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("journal line %d: %w", line, err)
+		}
+
+		switch record.Phase {
+		case phaseIntent:
+			if record.Command == nil {
+				return fmt.Errorf("journal line %d: intent without a command", line)
+			}
+			intents[record.Seq] = *record.Command
+		case phaseComplete:
+			cmd, exists := intents[record.Seq]
+			if !exists {
+				return fmt.Errorf("journal line %d: completion of unknown command %d", line, record.Seq)
+			}
+			delete(intents, record.Seq)
+			if _, err := b.execute(cmd); err != nil {
+				return fmt.Errorf("replaying command %d: %w", record.Seq, err)
+			}
+		default:
+			return fmt.Errorf("journal line %d: unknown phase %q", line, record.Phase)
+		}
+	}
+	return scanner.Err()
+}
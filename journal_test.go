@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestReplayJournal ensures replaying a journal into a fresh bank reproduces the
+// balances of the bank that wrote it.
+func TestReplayJournal(t *testing.T) {
+	var journal bytes.Buffer
+	bank := NewBankService()
+	bank.SetJournal(&journal)
+
+	commands := []Command{
+		{Op: OpCreateUser, UserID: 1, Role: Customer},
+		{Op: OpCreateUser, UserID: 2, Role: Customer},
+		{Op: OpCreateAccount, UserID: 1, Amount: 500, Currency: USD},
+		{Op: OpCreateAccount, UserID: 2, Amount: 100, Currency: USD},
+		{Op: OpDeposit, UserID: 1, Account: 0, Amount: 250},
+		{Op: OpTransfer, UserID: 1, From: 0, To: 1, Amount: 300, Memo: "rent"},
+		{Op: OpWithdraw, UserID: 2, Account: 1, Amount: 1000}, // Fails, so isn't replayed
+		{Op: OpWithdraw, UserID: 2, Account: 1, Amount: 50},
+		{Op: OpBalance, UserID: 1, Account: 0}, // Read-only, so isn't journaled
+	}
+	for _, cmd := range commands {
+		bank.Execute(cmd)
+	}
+
+	// Simulate a crash after a command's intent was journaled but before it completed.
+	journal.WriteString(`{"seq":99,"phase":"intent","command":{"op":"deposit","user":1,"account":0,"amount":1000}}` + "\n")
+
+	if lines := strings.Count(journal.String(), "\n"); lines != 16 {
+		t.Errorf("expected 16 journal records, got %d", lines)
+	}
+
+	replayed := NewBankService()
+	if err := replayed.Replay(&journal); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for userID, accID := range map[int]int{1: 0, 2: 1} {
+		expected, _, _ := bank.GetBalance(userID, accID)
+		got, _, err := replayed.GetBalance(userID, accID)
+		if err != nil {
+			t.Fatalf("expected account %d to be replayed, got %v", accID, err)
+		}
+		if got != expected {
+			t.Errorf("expected account %d balance %.2f, got %.2f", accID, expected, got)
+		}
+	}
+}
+
+// TestReplayJournalExchange ensures a journaled exchange replays into a fresh bank,
+// since the rate it used is journaled too.
+func TestReplayJournalExchange(t *testing.T) {
+	var journal bytes.Buffer
+	bank := NewBankService()
+	bank.SetJournal(&journal)
+
+	commands := []Command{
+		{Op: OpCreateUser, UserID: 1, Role: Customer},
+		{Op: OpCreateAccount, UserID: 1, Amount: 500, Currency: USD},
+		{Op: OpCreateAccount, UserID: 1, Amount: 0, Currency: EUR},
+		{Op: OpSetRate, Currency: USD, ToCurrency: EUR, Rate: 0.85},
+		{Op: OpExchange, UserID: 1, From: 0, To: 1, Amount: 100},
+	}
+	for _, cmd := range commands {
+		if _, err := bank.Execute(cmd); err != nil {
+			t.Fatalf("%s: expected no error, got %v", cmd.Op, err)
+		}
+	}
+
+	replayed := NewBankService()
+	if err := replayed.Replay(&journal); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := replayed.GetBalance(1, 1); balance != 85 {
+		t.Errorf("expected balance 85, got %.2f", balance)
+	}
+}
+
+// TestReplayJournalMalformed ensures replay stops at a malformed record.
+func TestReplayJournalMalformed(t *testing.T) {
+	bank := NewBankService()
+
+	tests := []string{
+		"not json\n",
+		`{"seq":1,"phase":"complete"}` + "\n",
+		`{"seq":1,"phase":"rollback"}` + "\n",
+	}
+	for _, journal := range tests {
+		if err := bank.Replay(strings.NewReader(journal)); err == nil {
+			t.Errorf("expected an error replaying %q", journal)
+		}
+	}
+}
+
+// failingWriter accepts a number of writes and then fails every one after.
+type failingWriter struct {
+	remaining int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.remaining == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.remaining--
+	return len(p), nil
+}
+
+// TestJournalCompletionWriteFailure ensures a command that took effect is reported
+// as successful even if its completion can't be journaled, and that later commands
+// are refused.
+func TestJournalCompletionWriteFailure(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetJournal(&failingWriter{remaining: 1}) // Only the first intent is written
+
+	if _, err := bank.Execute(Command{Op: OpDeposit, UserID: 1, Account: accID, Amount: 50}); err != nil {
+		t.Fatalf("expected the applied deposit to succeed, got %v", err)
+	}
+	if _, err := bank.Execute(Command{Op: OpDeposit, UserID: 1, Account: accID, Amount: 50}); err == nil {
+		t.Error("expected commands to be refused once the journal failed")
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 150 {
+		t.Errorf("expected balance 150, got %.2f", balance)
+	}
+
+	bank.SetJournal(nil)
+	if _, err := bank.Execute(Command{Op: OpDeposit, UserID: 1, Account: accID, Amount: 50}); err != nil {
+		t.Errorf("expected no error after resetting the journal, got %v", err)
+	}
+}
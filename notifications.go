@@ -0,0 +1,101 @@
+package main
+
+import "fmt"
+
+// NotificationType identifies the kind of notification sent to an account owner.
+type NotificationType string
+
+// Notification types
+const (
+	NotifyDeposit    NotificationType = "deposit"
+	NotifyLowBalance NotificationType = "low_balance"
+	NotifyFrozen     NotificationType = "account_frozen"
+)
+
+// Notification tells an account owner about something that happened to one of
+// their accounts.
+type Notification struct {
+	Type      NotificationType
+	AccountID int
+	Amount    float64
+	Message   string
+}
+
+// SetNotificationHandler registers the function that receives notifications about
+// the user's accounts, replacing any previous one. A nil handler stops notifications.
+// Handlers run in their own goroutine, so they may be called concurrently and
+// shouldn't assume any ordering.
+func (b *BankService) SetNotificationHandler(userID int, fn func(Notification)) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.users[userID]; !exists {
+		return userError(userID, ErrUserNotExist)
+	}
+	if fn == nil {
+		delete(b.notifyHandlers, userID)
+	} else {
+		b.notifyHandlers[userID] = fn
+	}
+	return nil
+}
+
+// SetLowBalanceThreshold makes the bank notify the account's owner when an outflow
+// takes the balance from at or above threshold to below it. A threshold of zero
+// disables the alert.
+func (b *BankService) SetLowBalanceThreshold(userID, accountID int, threshold float64) error {
+	if threshold < 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	account.lowBalance = threshold
+	fmt.Printf("User %d set low-balance threshold for account %d: %.2f\n", userID, accountID, threshold)
+	return nil
+}
+
+// crossedLowBalance reports whether tx, already applied, took the account's own
+// balance below its low-balance threshold. Balances that were already below it
+// don't trigger the alert again. The caller must hold the account lock.
+func (a *Account) crossedLowBalance(tx *Transaction) bool {
+	if a.lowBalance == 0 || tx.Currency != "" || !tx.Type.isOutflow() {
+		return false
+	}
+	return tx.Balance < a.lowBalance && tx.Balance+tx.Amount >= a.lowBalance
+}
+
+// notify dispatches the notification to the handler of the account's owner, if any.
+// It must be called without holding any bank or account locks.
+func (b *BankService) notify(n Notification) {
+	b.mutex.Lock()
+	fn := b.notifyHandlers[b.accounts[n.AccountID].ownerID]
+	b.mutex.Unlock()
+
+	if fn == nil {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Notification handler for account %d panicked: %v\n", n.AccountID, r)
+			}
+		}()
+		fn(n)
+	}()
+}
+
+// frozenNotification tells the owner that their account was frozen.
+func frozenNotification(accountID int) Notification {
+	return Notification{Type: NotifyFrozen, AccountID: accountID, Message: fmt.Sprintf("Account %d was frozen", accountID)}
+}
+
+// lowBalanceNotification tells the owner that their balance dropped below threshold.
+func lowBalanceNotification(accountID int, balance, threshold float64) Notification {
+	return Notification{Type: NotifyLowBalance, AccountID: accountID, Amount: balance, Message: fmt.Sprintf("Balance dropped to %.2f, below %.2f", balance, threshold)}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestNotificationHandlerDeposit ensures the account owner's handler is notified
+// of deposits.
+func TestNotificationHandlerDeposit(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	received := make(chan Notification, 1)
+	if err := bank.SetNotificationHandler(1, func(n Notification) { received <- n }); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	bank.Deposit(1, accID, 50)
+
+	select {
+	case n := <-received:
+		if n.Type != NotifyDeposit || n.AccountID != accID || n.Amount != 50 {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a deposit notification")
+	}
+}
+
+// TestNotificationHandlerPanic ensures a panicking handler doesn't affect the bank.
+func TestNotificationHandlerPanic(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Teller, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	done := make(chan struct{})
+	bank.SetNotificationHandler(1, func(n Notification) {
+		defer close(done)
+		panic("handler failure")
+	})
+
+	if err := bank.FreezeAccount(2, accID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the handler to be called")
+	}
+}
+
+// TestSetNotificationHandlerUnknownUser ensures handlers can't be registered for
+// users that don't exist.
+func TestSetNotificationHandlerUnknownUser(t *testing.T) {
+	bank := NewBankService()
+
+	err := bank.SetNotificationHandler(1, func(Notification) {})
+	if !errors.Is(err, ErrUserNotExist) {
+		t.Errorf("expected ErrUserNotExist, got %v", err)
+	}
+}
+
+// TestLowBalanceThreshold ensures the low-balance alert fires once when the balance
+// crosses the threshold, and again only after it has recovered.
+func TestLowBalanceThreshold(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+	otherID, _ := bank.CreateAccount(1, 0, USD)
+
+	alerts := make(chan Notification, 10)
+	bank.SetNotificationHandler(1, func(n Notification) {
+		if n.Type == NotifyLowBalance {
+			alerts <- n
+		}
+	})
+	if err := bank.SetLowBalanceThreshold(1, accID, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	bank.Withdraw(1, accID, 300)      // 200, above the threshold
+	bank.Withdraw(1, accID, 150)      // 50, crosses it
+	bank.Withdraw(1, accID, 20)       // 30, already below
+	bank.Transfer(accID, otherID, 10) // 20, already below
+
+	select {
+	case n := <-alerts:
+		if n.AccountID != accID || n.Amount != 50 {
+			t.Errorf("unexpected alert %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a low-balance alert")
+	}
+	select {
+	case n := <-alerts:
+		t.Fatalf("expected a single alert, got another %+v", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	bank.Deposit(1, accID, 200)        // 220, recovered
+	bank.Transfer(accID, otherID, 150) // 70, crosses again
+	select {
+	case n := <-alerts:
+		if n.Amount != 70 {
+			t.Errorf("expected alert at balance 70, got %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a second alert after recovery")
+	}
+}
+
+// TestSetLowBalanceThresholdInvalid ensures negative thresholds are rejected.
+func TestSetLowBalanceThresholdInvalid(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	if err := bank.SetLowBalanceThreshold(1, accID, -1); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("expected ErrInvalidAmount, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// TransferVia moves amount along a chain of accounts, e.g. to consolidate funds
+// through an intermediary: from path[0] to path[1], then on to path[2], and so on.
+// The accounts must be distinct and share a currency, and the user must be able to
+// debit every account but the last. All hops are applied together or not at all,
+// so intermediaries end up unchanged.
+func (b *BankService) TransferVia(userID int, path []int, amount float64) error {
+	defer b.observeDuration(OpTransfer, time.Now())
+
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if len(path) < 2 {
+		return ErrInvalidPath
+	}
+	seen := make(map[int]bool, len(path))
+	for _, accountID := range path {
+		if seen[accountID] {
+			return ErrInvalidPath
+		}
+		seen[accountID] = true
+	}
+
+	accounts := make([]*Account, len(path))
+	for i, accountID := range path {
+		var err error
+		if i < len(path)-1 {
+			err = b.checkDebitPermissions(userID, accountID)
+		} else {
+			_, err = b.getAccount(accountID)
+		}
+		if err != nil {
+			return err
+		}
+		accounts[i] = b.lookupAccount(accountID)
+		if accounts[i].currency != accounts[0].currency {
+			return ErrCurrencyMismatch
+		}
+	}
+	if err := b.checkTransactionLimit(userID, OpTransfer, amount); err != nil {
+		return err
+	}
+	if err := b.throttle(path[0]); err != nil {
+		return err
+	}
+
+	defer b.queueAll(path, accounts)()
+	unlock, err := lockAll(path, accounts, b.getLockTimeout())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Validate every hop before applying any, so a failing hop leaves nothing to undo.
+	source, last := accounts[0], len(path)-1
+	if source.available()+source.overdraftLimit < amount {
+		return ErrInsufficientBalance
+	}
+	for i, account := range accounts {
+		if i < last && account.frozen {
+			return accountError(path[i], ErrAccountFrozen)
+		}
+		if i > 0 && account.closed {
+			return accountError(path[i], ErrAccountClosed)
+		}
+	}
+	if err := accounts[last].checkCredit(path[last], amount); err != nil {
+		return err
+	}
+
+	for i := 0; i < last; i++ {
+		from, to := accounts[i], accounts[i+1]
+		from.balance -= amount
+		to.balance += amount
+		b.recordTransaction(path[i], from, TxTransferOut, amount, path[i+1])
+		b.recordTransaction(path[i+1], to, TxTransferIn, amount, path[i])
+	}
+	fmt.Printf("Transferred %.2f from account %d to account %d via %d accounts\n", amount, path[0], path[last], last-1)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestTransferVia ensures funds moved along a path only change the endpoints.
+func TestTransferVia(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	a, _ := bank.CreateAccount(1, 500, USD)
+	b, _ := bank.CreateAccount(1, 20, USD)
+	c, _ := bank.CreateAccount(1, 100, USD)
+
+	if err := bank.TransferVia(1, []int{a, b, c}, 300); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for accID, expected := range map[int]float64{a: 200, b: 20, c: 400} {
+		if balance, _, _ := bank.GetBalance(1, accID); balance != expected {
+			t.Errorf("expected account %d balance %.2f, got %.2f", accID, expected, balance)
+		}
+	}
+	if history, _ := bank.GetTransactionHistory(1, b); len(history) != 3 {
+		t.Errorf("expected the intermediary to record both hops, got %d entries", len(history))
+	}
+}
+
+// TestTransferViaRollback ensures a failing hop leaves every account untouched.
+func TestTransferViaRollback(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Teller, false)
+	a, _ := bank.CreateAccount(1, 500, USD)
+	b, _ := bank.CreateAccount(1, 0, USD)
+	c, _ := bank.CreateAccount(1, 0, USD)
+	eur, _ := bank.CreateAccount(1, 0, EUR)
+	bank.FreezeAccount(2, b)
+
+	tests := []struct {
+		name     string
+		path     []int
+		expected error
+	}{
+		{"frozen intermediary", []int{a, b, c}, ErrAccountFrozen},
+		{"mixed currencies", []int{a, c, eur}, ErrCurrencyMismatch},
+		{"repeated account", []int{a, c, a}, ErrInvalidPath},
+		{"single account", []int{a}, ErrInvalidPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bank.TransferVia(1, tt.path, 100); !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if balance, _, _ := bank.GetBalance(1, a); balance != 500 {
+				t.Errorf("expected source balance 500, got %.2f", balance)
+			}
+		})
+	}
+}
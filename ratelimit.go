@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// SetAccountRateLimit limits deposits, withdrawals, transfers, exchanges, refunds and
+// holds on the account to maxPerMinute, failing further operations with
+// ErrRateLimited. Transfers count against the source account only. The
+// limit is a token bucket driven by the bank's clock, so short bursts of up to
+// maxPerMinute operations are allowed. Zero removes the limit.
+func (b *BankService) SetAccountRateLimit(accountID int, maxPerMinute int) error {
+	if maxPerMinute < 0 {
+		return ErrInvalidAmount
+	}
+
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+	now := b.clock()
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	account.rateLimit = maxPerMinute
+	account.tokens = float64(maxPerMinute)
+	account.lastRefill = now
+	fmt.Printf("Set rate limit for account %d: %d per minute\n", accountID, maxPerMinute)
+	return nil
+}
+
+// throttle takes a token from the account's rate limit bucket, failing with
+// ErrRateLimited if none is left. It must be called without holding the account lock.
+func (b *BankService) throttle(accountID int) error {
+	account := b.lookupAccount(accountID)
+	now := b.clock()
+
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return err
+	}
+	defer account.mutex.Unlock()
+
+	if account.rateLimit == 0 {
+		return nil
+	}
+
+	limit := float64(account.rateLimit)
+	elapsed := now.Sub(account.lastRefill)
+	if elapsed > 0 {
+		account.tokens = math.Min(limit, account.tokens+limit*elapsed.Minutes())
+		account.lastRefill = now
+	}
+	if account.tokens < 1 {
+		return accountError(accountID, ErrRateLimited)
+	}
+	account.tokens--
+	return nil
+}
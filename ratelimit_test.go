@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestAccountRateLimit ensures rapid operations hit the limit and are allowed again
+// once the clock has moved on.
+func TestAccountRateLimit(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	if err := bank.SetAccountRateLimit(accID, 3); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := bank.Deposit(1, accID, 10); err != nil {
+			t.Fatalf("deposit %d: expected no error, got %v", i+1, err)
+		}
+	}
+	if err := bank.Deposit(1, accID, 10); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if err := bank.Withdraw(1, accID, 10); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected withdrawals to share the limit, got %v", err)
+	}
+
+	clock.Advance(20 * time.Second) // One token at 3 per minute
+	if err := bank.Deposit(1, accID, 10); err != nil {
+		t.Fatalf("expected no error after recovery, got %v", err)
+	}
+	if err := bank.Deposit(1, accID, 10); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 140 {
+		t.Errorf("expected balance 140, got %.2f", balance)
+	}
+}
+
+// TestAccountRateLimitCoversAllDebits ensures operations besides plain deposits,
+// withdrawals and transfers count against the account's limit.
+func TestAccountRateLimitCoversAllDebits(t *testing.T) {
+	bank := NewBankService()
+	bank.SetClock(newFakeClock().Now)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	otherID, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetAccountRateLimit(accID, 1)
+
+	if _, err := bank.PlaceHold(1, accID, 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := bank.PlaceHold(1, accID, 10); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected PlaceHold to be rate limited, got %v", err)
+	}
+	if _, err := bank.WithdrawToExternal(1, accID, 10, "WIRE-1"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected WithdrawToExternal to be rate limited, got %v", err)
+	}
+	if err := bank.TransferVia(1, []int{accID, otherID}, 10); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected TransferVia to be rate limited, got %v", err)
+	}
+
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 100 {
+		t.Errorf("expected balance 100, got %.2f", balance)
+	}
+}
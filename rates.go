@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetRateStalenessLimit sets how old an exchange rate may be before ExchangeCurrency
+// refuses to use it. A zero duration disables the check.
+func (b *BankService) SetRateStalenessLimit(d time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rateStaleness = d
+	fmt.Printf("Set exchange rate staleness limit: %s\n", d)
+}
+
+// SetStaleRateWarnOnly controls whether exchanging at a stale rate fails with
+// ErrStaleExchangeRate (the default) or proceeds and emits EventStaleExchangeRate.
+func (b *BankService) SetStaleRateWarnOnly(warnOnly bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.staleRateWarnOnly = warnOnly
+}
+
+// getTradingRate retrieves the exchange rate to use for an exchange, enforcing the
+// staleness limit. It reports stale rates that are allowed in warn-only mode.
+// Rates from the rate provider are live and never stale.
+func (b *BankService) getTradingRate(from, to string) (rate float64, stale bool, err error) {
+	key := from + ":" + to
+	now := b.clock()
+
+	b.mutex.Lock()
+	rate, exists := b.exchangeRates[key]
+	updated, staleness, warnOnly := b.rateUpdated[key], b.rateStaleness, b.staleRateWarnOnly
+	b.mutex.Unlock()
+
+	if !exists {
+		rate, err = b.providerRate(from, to)
+		return rate, false, err
+	}
+	if staleness == 0 || now.Sub(updated) <= staleness {
+		return rate, false, nil
+	}
+	if !warnOnly {
+		return 0, false, ErrStaleExchangeRate
+	}
+	return rate, true, nil
+}
+
+// SetExchangeRateBounds restricts the rates SetExchangeRate accepts for a currency
+// pair to [min, max], guarding against mistyped rates.
+func (b *BankService) SetExchangeRateBounds(from, to string, min, max float64) error {
+	if min > max {
+		return ErrInvalidAmount
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rateBounds[from+":"+to] = [2]float64{min, max}
+	fmt.Printf("Set exchange rate bounds %s -> %s: [%.4f, %.4f]\n", from, to, min, max)
+	return nil
+}
+
+// AvailablePairs returns the [from, to] currency pairs with a rate set by
+// SetExchangeRate, sorted by source and then destination currency. Pairs only the
+// rate provider can price aren't listed, since providers can't be enumerated.
+func (b *BankService) AvailablePairs() [][2]string {
+	b.mutex.Lock()
+	pairs := make([][2]string, 0, len(b.exchangeRates))
+	for key := range b.exchangeRates {
+		from, to, _ := strings.Cut(key, ":")
+		pairs = append(pairs, [2]string{from, to})
+	}
+	b.mutex.Unlock()
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
+
+// validRate reports whether rate is a usable exchange rate. A zero rate would
+// destroy the exchanged funds, and its inverse is undefined.
+func validRate(rate float64) bool {
+	return rate > 0 && !math.IsInf(rate, 1)
+}
+
+// RateProvider supplies exchange rates, for example from a live FX feed.
+type RateProvider interface {
+	// Rate returns the rate converting from into to, or false if it has none.
+	Rate(from, to string) (float64, bool)
+}
+
+// SetRateProvider installs a provider consulted for currency pairs without a rate
+// set by SetExchangeRate. A nil provider removes it.
+func (b *BankService) SetRateProvider(p RateProvider) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rateProvider = p
+}
+
+// providerRate retrieves a rate from the rate provider, subject to the pair's bounds.
+// The provider is called without holding the bank lock.
+func (b *BankService) providerRate(from, to string) (float64, error) {
+	b.mutex.Lock()
+	provider := b.rateProvider
+	bounds, bounded := b.rateBounds[from+":"+to]
+	b.mutex.Unlock()
+
+	if provider == nil {
+		return 0, ErrExchangeRateNotFound
+	}
+	rate, ok := provider.Rate(from, to)
+	if !ok || !validRate(rate) {
+		return 0, ErrExchangeRateNotFound
+	}
+	if bounded && (rate < bounds[0] || rate > bounds[1]) {
+		return 0, ErrRateOutOfBounds
+	}
+	return rate, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestStaleExchangeRate ensures exchanges fail once the rate is older than the limit.
+func TestStaleExchangeRate(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, ExchangeManager, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, EUR)
+
+	bank.SetExchangeRate(USD, EUR, 0.85)
+	bank.SetRateStalenessLimit(time.Hour)
+
+	if err := bank.ExchangeCurrency(1, acc1, acc2, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	clock.Advance(2 * time.Hour)
+
+	err := bank.ExchangeCurrency(1, acc1, acc2, 100)
+	if !errors.Is(err, ErrStaleExchangeRate) {
+		t.Fatalf("expected ErrStaleExchangeRate, got %v", err)
+	}
+
+	// Refreshing the rate makes it usable again.
+	bank.SetExchangeRate(USD, EUR, 0.86)
+	if err := bank.ExchangeCurrency(1, acc1, acc2, 100); err != nil {
+		t.Fatalf("expected no error after refresh, got %v", err)
+	}
+}
+
+// TestStaleExchangeRateWarnOnly ensures warn-only mode proceeds and emits an event.
+func TestStaleExchangeRateWarnOnly(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, ExchangeManager, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, EUR)
+
+	bank.SetExchangeRate(USD, EUR, 0.85)
+	bank.SetRateStalenessLimit(time.Hour)
+	bank.SetStaleRateWarnOnly(true)
+
+	var warnings int
+	bank.Subscribe(func(e Event) {
+		if e.Type == EventStaleExchangeRate {
+			warnings++
+		}
+	})
+
+	clock.Advance(2 * time.Hour)
+
+	if err := bank.ExchangeCurrency(1, acc1, acc2, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if warnings != 1 {
+		t.Errorf("expected 1 stale rate warning, got %d", warnings)
+	}
+}
+
+// TestExchangeRateBounds ensures rates outside the configured band are rejected.
+func TestExchangeRateBounds(t *testing.T) {
+	bank := NewBankService()
+	bank.SetExchangeRateBounds(USD, EUR, 0.5, 1.5)
+
+	if err := bank.SetExchangeRate(USD, EUR, 0.85); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err := bank.SetExchangeRate(USD, EUR, 8.5)
+	if !errors.Is(err, ErrRateOutOfBounds) {
+		t.Fatalf("expected ErrRateOutOfBounds, got %v", err)
+	}
+
+	rate, _ := bank.getExchangeRate(USD, EUR)
+	if rate != 0.85 {
+		t.Errorf("expected rate to remain 0.85, got %.2f", rate)
+	}
+}
+
+// fakeRateProvider serves fixed rates keyed by "FROM:TO".
+type fakeRateProvider map[string]float64
+
+func (p fakeRateProvider) Rate(from, to string) (float64, bool) {
+	rate, ok := p[from+":"+to]
+	return rate, ok
+}
+
+// TestRateProvider ensures the provider supplies rates missing from the in-memory store.
+func TestRateProvider(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.SetRateStalenessLimit(time.Hour)
+	bank.CreateUser(1, Customer, false)
+
+	usdID, _ := bank.CreateAccount(1, 100, USD)
+	eurID, _ := bank.CreateAccount(1, 0, EUR)
+	gbpID, _ := bank.CreateAccount(1, 0, GBP)
+
+	if err := bank.ExchangeCurrency(1, usdID, eurID, 50); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Fatalf("expected ErrExchangeRateNotFound, got %v", err)
+	}
+
+	bank.SetRateProvider(fakeRateProvider{"USD:EUR": 0.9})
+	clock.Advance(2 * time.Hour)
+	if err := bank.ExchangeCurrency(1, usdID, eurID, 50); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, eurID); balance != 45 {
+		t.Errorf("expected balance 45, got %.2f", balance)
+	}
+
+	if err := bank.ExchangeCurrency(1, usdID, gbpID, 50); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Errorf("expected ErrExchangeRateNotFound, got %v", err)
+	}
+}
+
+// TestAvailablePairs ensures every pair with a configured rate is listed in order.
+func TestAvailablePairs(t *testing.T) {
+	bank := NewBankService()
+	bank.SetExchangeRate(USD, EUR, 0.9)
+	bank.SetExchangeRate(GBP, USD, 1.25)
+	bank.SetExchangeRate(EUR, USD, 1.1)
+	bank.SetExchangeRate(USD, EUR, 0.92) // Updating a rate doesn't duplicate the pair
+
+	expected := [][2]string{{EUR, USD}, {GBP, USD}, {USD, EUR}}
+	if pairs := bank.AvailablePairs(); !slices.Equal(pairs, expected) {
+		t.Errorf("expected %v, got %v", expected, pairs)
+	}
+}
+
+// TestExchangeRecordsRate ensures both sides of an exchange record the rate used.
+func TestExchangeRecordsRate(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, ExchangeManager, false)
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetExchangeRate(USD, EUR, 0.85)
+
+	if err := bank.ExchangeCurrency(1, acc1, acc2, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, accID := range []int{acc1, acc2} {
+		history, _ := bank.GetTransactionHistory(1, accID)
+		if last := history[len(history)-1]; last.Rate != 0.85 {
+			t.Errorf("expected account %d to record rate 0.85, got %v", accID, last.Rate)
+		}
+	}
+	if err := bank.VerifyAuditChain(acc1); err != nil {
+		t.Errorf("expected intact audit chain, got %v", err)
+	}
+}
+
+// TestSetExchangeRateInvalid ensures non-positive rates are rejected and the
+// previous rate stays in effect.
+func TestSetExchangeRateInvalid(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, ExchangeManager, false)
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetExchangeRate(USD, EUR, 0.85)
+
+	for _, rate := range []float64{0, -0.85, math.NaN(), math.Inf(1)} {
+		if err := bank.SetExchangeRate(USD, EUR, rate); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("expected ErrInvalidRate for rate %v, got %v", rate, err)
+		}
+	}
+
+	bank.ExchangeCurrency(1, acc1, acc2, 100)
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 85 {
+		t.Errorf("expected balance 85, got %.2f", balance)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScheduledTransfer is a transfer to be executed by ProcessScheduledTransfers once
+// its time arrives.
+type ScheduledTransfer struct {
+	ID     int64
+	UserID int // User who scheduled the transfer
+	FromID int
+	ToID   int
+	Amount float64
+	At     time.Time
+	holdID int64 // Hold reserving the funds, zero if not reserved
+
+	SkipWeekends bool // Defer to the next business day if At falls on a weekend or holiday
+}
+
+// ScheduleTransfer schedules a transfer for the given time and returns its ID. With
+// reserve set, the amount is held on the source account right away, so the transfer
+// can't later fail for lack of funds.
+func (b *BankService) ScheduleTransfer(userID, fromID, toID int, amount float64, at time.Time, reserve bool) (int64, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return 0, err
+	}
+	if err := b.checkTransactionLimit(userID, OpTransfer, amount); err != nil {
+		return 0, err
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return 0, err
+	}
+	fromAccount := b.lookupAccount(fromID)
+	if fromAccount.currency != toAccount.currency {
+		return 0, ErrCurrencyMismatch
+	}
+
+	transfer := &ScheduledTransfer{UserID: userID, FromID: fromID, ToID: toID, Amount: amount, At: at}
+	if reserve {
+		transfer.holdID = b.registerHold(fromID)
+		if err := b.reserve(fromID, fromAccount, transfer.holdID, amount, false); err != nil {
+			b.unregisterHold(transfer.holdID)
+			return 0, err
+		}
+	}
+
+	b.mutex.Lock()
+	b.nextScheduleID++
+	transfer.ID = b.nextScheduleID
+	b.scheduled[transfer.ID] = transfer
+	b.mutex.Unlock()
+
+	fmt.Printf("User %d scheduled transfer %d of %.2f from account %d to account %d at %s\n",
+		userID, transfer.ID, amount, fromID, toID, at.Format(time.RFC3339))
+	return transfer.ID, nil
+}
+
+// CancelScheduledTransfer cancels a pending scheduled transfer and releases any
+// funds it reserved. Only the user who scheduled it or a Banker can cancel it.
+func (b *BankService) CancelScheduledTransfer(userID int, transferID int64) error {
+	b.mutex.Lock()
+	transfer, exists := b.scheduled[transferID]
+	if !exists {
+		b.mutex.Unlock()
+		return ErrScheduledTransferNotFound
+	}
+	if user, ok := b.users[userID]; transfer.UserID != userID && (!ok || user.Role != Banker) {
+		b.mutex.Unlock()
+		return userError(userID, ErrUnauthorizedAccess)
+	}
+	delete(b.scheduled, transferID)
+	b.mutex.Unlock()
+
+	if transfer.holdID != 0 {
+		if err := b.ReleaseHold(transfer.holdID); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("User %d canceled scheduled transfer %d\n", userID, transferID)
+	return nil
+}
+
+// SetScheduleSkipWeekends sets whether a pending scheduled transfer due on a weekend
+// or holiday is deferred to the next business day. Only the user who scheduled it or
+// a Banker can change it.
+func (b *BankService) SetScheduleSkipWeekends(userID int, transferID int64, skip bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	transfer, exists := b.scheduled[transferID]
+	if !exists {
+		return ErrScheduledTransferNotFound
+	}
+	if user, ok := b.users[userID]; transfer.UserID != userID && (!ok || user.Role != Banker) {
+		return userError(userID, ErrUnauthorizedAccess)
+	}
+	transfer.SkipWeekends = skip
+	fmt.Printf("User %d set skip weekends for scheduled transfer %d: %t\n", userID, transferID, skip)
+	return nil
+}
+
+// SetHolidays replaces the days, in addition to weekends, on which scheduled
+// transfers that skip weekends don't run. Only the date of each time matters.
+func (b *BankService) SetHolidays(days []time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.holidays = make(map[string]bool, len(days))
+	for _, d := range days {
+		b.holidays[d.Format(time.DateOnly)] = true
+	}
+	fmt.Printf("Set %d bank holidays\n", len(b.holidays))
+}
+
+// runAt returns when the scheduled transfer is due, deferring transfers that skip
+// weekends to the same time on the next business day. The caller must hold the
+// bank lock.
+func (b *BankService) runAt(transfer *ScheduledTransfer) time.Time {
+	at := transfer.At
+	if !transfer.SkipWeekends {
+		return at
+	}
+	for at.Weekday() == time.Saturday || at.Weekday() == time.Sunday || b.holidays[at.Format(time.DateOnly)] {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+// ScheduleInfo is a read-only view of a pending scheduled transfer.
+type ScheduleInfo struct {
+	ID       int64
+	FromID   int
+	ToID     int
+	Amount   float64
+	NextRun  time.Time
+	Interval time.Duration // Repeat interval, zero for a one-time transfer
+	Reserved bool          // Whether the amount is held on the source account
+}
+
+// ListSchedules returns the user's pending scheduled transfers, soonest first.
+// Scheduled transfers run once, so their interval is always zero.
+func (b *BankService) ListSchedules(userID int) []ScheduleInfo {
+	b.mutex.Lock()
+	var schedules []ScheduleInfo
+	for _, transfer := range b.scheduled {
+		if transfer.UserID != userID {
+			continue
+		}
+		schedules = append(schedules, ScheduleInfo{
+			ID:       transfer.ID,
+			FromID:   transfer.FromID,
+			ToID:     transfer.ToID,
+			Amount:   transfer.Amount,
+			NextRun:  b.runAt(transfer),
+			Reserved: transfer.holdID != 0,
+		})
+	}
+	b.mutex.Unlock()
+
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].NextRun.Equal(schedules[j].NextRun) {
+			return schedules[i].NextRun.Before(schedules[j].NextRun)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules
+}
+
+// ProcessScheduledTransfers executes every scheduled transfer due by now, earliest
+// first, and returns how many succeeded. Failed transfers are logged and dropped.
+// Transfers that skip weekends wait for the next business day, and run in order of
+// that day rather than their original date.
+func (b *BankService) ProcessScheduledTransfers(now time.Time) int {
+	b.mutex.Lock()
+	var due []*ScheduledTransfer
+	runAt := make(map[int64]time.Time)
+	for id, transfer := range b.scheduled {
+		if at := b.runAt(transfer); !at.After(now) {
+			due = append(due, transfer)
+			runAt[id] = at
+			delete(b.scheduled, id)
+		}
+	}
+	b.mutex.Unlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		if at, other := runAt[due[i].ID], runAt[due[j].ID]; !at.Equal(other) {
+			return at.Before(other)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	executed := 0
+	for _, transfer := range due {
+		var err error
+		if transfer.holdID != 0 {
+			err = b.checkDebitPermissions(transfer.UserID, transfer.FromID)
+			if err == nil {
+				err = b.transferHeld(transfer.holdID, transfer.ToID, transfer.Amount)
+			} else if releaseErr := b.ReleaseHold(transfer.holdID); releaseErr != nil {
+				fmt.Printf("Scheduled transfer %d could not release hold %d: %v\n", transfer.ID, transfer.holdID, releaseErr)
+			}
+		} else {
+			_, err = b.TransferWithMemo(transfer.UserID, transfer.FromID, transfer.ToID, transfer.Amount, "")
+		}
+
+		if err != nil {
+			fmt.Printf("Scheduled transfer %d failed: %v\n", transfer.ID, err)
+			continue
+		}
+		executed++
+	}
+	return executed
+}
+
+// transferHeld moves amount, which must not exceed the hold, from the held account to
+// toID, releasing the rest of the hold.
+func (b *BankService) transferHeld(holdID int64, toID int, amount float64) error {
+	b.mutex.Lock()
+	fromID, exists := b.holdAccounts[holdID]
+	b.mutex.Unlock()
+	if !exists {
+		return ErrHoldNotFound
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return err
+	}
+	fromAccount := b.lookupAccount(fromID)
+	if fromAccount.currency != toAccount.currency {
+		return ErrCurrencyMismatch
+	}
+
+	defer b.queueAccounts(fromID, fromAccount, toID, toAccount)()
+	unlock, err := lockAccounts(fromID, fromAccount, toID, toAccount, b.getLockTimeout())
+	if err != nil {
+		return err
+	}
+
+	h, exists := fromAccount.activeHolds[holdID]
+	switch {
+	case !exists:
+		err = ErrHoldNotFound
+	case amount > h.amount:
+		err = ErrInvalidAmount
+	case fromAccount.frozen:
+		err = accountError(fromID, ErrAccountFrozen)
+	case toAccount.closed:
+		err = accountError(toID, ErrAccountClosed)
+	default:
+		err = toAccount.checkCredit(toID, amount)
+	}
+	if err != nil {
+		unlock()
+		return err
+	}
+
+	fromAccount.removeHold(holdID)
+	fromAccount.balance -= amount
+	toAccount.balance += amount
+	b.record(fromID, fromAccount, Transaction{Type: TxTransferOut, Amount: amount, Counterparty: toID})
+	b.record(toID, toAccount, Transaction{Type: TxTransferIn, Amount: amount, Counterparty: fromID})
+	unlock()
+
+	b.unregisterHold(holdID)
+	fmt.Printf("Transferred %.2f held by hold %d from account %d to account %d\n", amount, holdID, fromID, toID)
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestScheduleTransfer ensures scheduled transfers run once due.
+func TestScheduleTransfer(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	if _, err := bank.ScheduleTransfer(1, acc1, acc2, 60, clock.Now().Add(day), false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 0 {
+		t.Fatalf("expected no transfers before due time, got %d", executed)
+	}
+	clock.Advance(day)
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 1 {
+		t.Fatalf("expected 1 transfer executed, got %d", executed)
+	}
+
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 60 {
+		t.Errorf("expected balance 60, got %.2f", balance)
+	}
+}
+
+// TestScheduleReservedTransfer ensures reserved funds are unavailable until the
+// transfer runs or is canceled.
+func TestScheduleReservedTransfer(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	transferID, err := bank.ScheduleTransfer(1, acc1, acc2, 60, clock.Now().Add(day), true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.Withdraw(1, acc1, 50); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected reserved funds to be unavailable, got %v", err)
+	}
+
+	if err := bank.CancelScheduledTransfer(1, transferID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.Withdraw(1, acc1, 50); err != nil {
+		t.Fatalf("expected canceled reservation to be released, got %v", err)
+	}
+
+	bank.ScheduleTransfer(1, acc1, acc2, 50, clock.Now().Add(day), true)
+	clock.Advance(day)
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 1 {
+		t.Fatalf("expected 1 transfer executed, got %d", executed)
+	}
+
+	balance1, _, _ := bank.GetBalance(1, acc1)
+	balance2, _, _ := bank.GetBalance(1, acc2)
+	if balance1 != 0 || balance2 != 50 {
+		t.Errorf("expected balances 0 and 50, got %.2f and %.2f", balance1, balance2)
+	}
+}
+
+// TestListSchedules ensures a user's pending transfers are listed soonest first.
+func TestListSchedules(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(2, 100, USD)
+
+	later, _ := bank.ScheduleTransfer(1, acc1, acc2, 20, clock.Now().Add(7*day), false)
+	sooner, _ := bank.ScheduleTransfer(1, acc1, acc2, 30, clock.Now().Add(day), true)
+	bank.ScheduleTransfer(2, acc2, acc1, 10, clock.Now().Add(day), false)
+
+	expected := []ScheduleInfo{
+		{ID: sooner, FromID: acc1, ToID: acc2, Amount: 30, NextRun: clock.Now().Add(day), Reserved: true},
+		{ID: later, FromID: acc1, ToID: acc2, Amount: 20, NextRun: clock.Now().Add(7 * day)},
+	}
+	if schedules := bank.ListSchedules(1); !slices.Equal(schedules, expected) {
+		t.Errorf("expected %+v, got %+v", expected, schedules)
+	}
+}
+
+// TestScheduleSkipWeekends ensures transfers that skip weekends wait until Monday.
+func TestScheduleSkipWeekends(t *testing.T) {
+	clock := newFakeClock() // Monday, January 1
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	saturday := clock.Now().Add(5 * day)
+	transferID, _ := bank.ScheduleTransfer(1, acc1, acc2, 10, saturday, false)
+	bank.ScheduleTransfer(1, acc1, acc2, 20, saturday, false) // Runs on the weekend
+	if err := bank.SetScheduleSkipWeekends(1, transferID, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	clock.Advance(6 * day) // Sunday
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 1 {
+		t.Fatalf("expected only the regular transfer on the weekend, got %d", executed)
+	}
+
+	clock.Advance(day) // Monday
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 1 {
+		t.Fatalf("expected 1 transfer on Monday, got %d", executed)
+	}
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 30 {
+		t.Errorf("expected balance 30, got %.2f", balance)
+	}
+}
+
+// TestScheduleSkipHolidays ensures a transfer deferred past the weekend also skips
+// a holiday.
+func TestScheduleSkipHolidays(t *testing.T) {
+	clock := newFakeClock() // Monday, January 1
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	saturday := clock.Now().Add(5 * day)
+	monday := saturday.Add(2 * day)
+	transferID, _ := bank.ScheduleTransfer(1, acc1, acc2, 10, saturday, false)
+	bank.SetScheduleSkipWeekends(1, transferID, true)
+	bank.SetHolidays([]time.Time{monday})
+
+	if schedules := bank.ListSchedules(1); !schedules[0].NextRun.Equal(monday.Add(day)) {
+		t.Errorf("expected next run %s, got %s", monday.Add(day), schedules[0].NextRun)
+	}
+	if executed := bank.ProcessScheduledTransfers(monday); executed != 0 {
+		t.Fatalf("expected no transfers on a holiday, got %d", executed)
+	}
+	if executed := bank.ProcessScheduledTransfers(monday.Add(day)); executed != 1 {
+		t.Fatalf("expected 1 transfer on Tuesday, got %d", executed)
+	}
+}
+
+// TestScheduleSkipWeekendsOrder ensures a transfer deferred past the weekend runs
+// after one that fell due on the weekend, even though it was scheduled earlier.
+func TestScheduleSkipWeekendsOrder(t *testing.T) {
+	clock := newFakeClock() // Monday, January 1
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	deferredID, _ := bank.ScheduleTransfer(1, acc1, acc2, 80, clock.Now().Add(5*day), false) // Saturday
+	bank.SetScheduleSkipWeekends(1, deferredID, true)
+	bank.ScheduleTransfer(1, acc1, acc2, 50, clock.Now().Add(6*day), false) // Sunday
+
+	clock.Advance(7 * day) // Monday, both are due
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 1 {
+		t.Fatalf("expected 1 transfer executed, got %d", executed)
+	}
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 50 {
+		t.Errorf("expected the Sunday transfer of 50 to run first, got balance %.2f", balance)
+	}
+}
+
+// TestScheduleReservedTransferOutlivesHoldExpiry ensures funds reserved for a
+// scheduled transfer aren't released by hold expiry before it runs.
+func TestScheduleReservedTransferOutlivesHoldExpiry(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.SetHoldExpiry(day)
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+	bank.ScheduleTransfer(1, acc1, acc2, 60, clock.Now().Add(3*day), true)
+
+	clock.Advance(2 * day)
+	if expired := bank.ProcessExpiredHolds(clock.Now()); expired != 0 {
+		t.Fatalf("expected the reservation not to expire, got %d expired", expired)
+	}
+	clock.Advance(day)
+	if executed := bank.ProcessScheduledTransfers(clock.Now()); executed != 1 {
+		t.Fatalf("expected 1 transfer executed, got %d", executed)
+	}
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 60 {
+		t.Errorf("expected balance 60, got %.2f", balance)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// SeedOptions controls the demo data generated by Seed.
+type SeedOptions struct {
+	Seed            int64    // Seed for the random generator; equal seeds produce equal data
+	Users           int      // Number of customers to create
+	AccountsPerUser int      // Number of accounts for each customer
+	MaxBalance      float64  // Opening balances are drawn from [0, MaxBalance)
+	Currencies      []string // Currencies to open accounts in, USD, EUR and GBP if empty
+}
+
+// Seed populates the bank with demo customers and accounts with random opening
+// balances, and sets exchange rates between every pair of the currencies. Users get
+// the IDs following the highest existing user ID. Given the same options, Seed
+// produces the same data in a fresh bank.
+func (b *BankService) Seed(opts SeedOptions) error {
+	if opts.Users < 0 || opts.AccountsPerUser < 0 || opts.MaxBalance < 0 {
+		return ErrInvalidAmount
+	}
+	currencies := opts.Currencies
+	if len(currencies) == 0 {
+		currencies = []string{USD, EUR, GBP}
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	for i, from := range currencies {
+		for _, to := range currencies[i+1:] {
+			rate := math.Round((0.5+rng.Float64())*10000) / 10000
+			if err := b.SetExchangeRate(from, to, rate); err != nil {
+				return err
+			}
+			if err := b.SetExchangeRate(to, from, 1/rate); err != nil {
+				return err
+			}
+		}
+	}
+
+	firstUserID := b.nextUserID()
+	for i := 0; i < opts.Users; i++ {
+		userID := firstUserID + i
+		if err := b.CreateUser(userID, Customer, rng.Intn(2) == 1); err != nil {
+			return err
+		}
+		for j := 0; j < opts.AccountsPerUser; j++ {
+			currency := currencies[rng.Intn(len(currencies))]
+			balance := b.roundToMinorUnits(rng.Float64()*opts.MaxBalance, currency)
+			if _, err := b.CreateAccount(userID, balance, currency); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("Seeded %d users with %d accounts each\n", opts.Users, opts.AccountsPerUser)
+	return nil
+}
+
+// nextUserID returns the ID following the highest existing user ID, or 1 if there
+// are no users.
+func (b *BankService) nextUserID() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	next := 1
+	for userID := range b.users {
+		if userID >= next {
+			next = userID + 1
+		}
+	}
+	return next
+}
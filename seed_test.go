@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestSeedDeterministic ensures seeding with the same seed produces identical state.
+func TestSeedDeterministic(t *testing.T) {
+	opts := SeedOptions{Seed: 42, Users: 5, AccountsPerUser: 3, MaxBalance: 10000}
+
+	seeded := func(opts SeedOptions) BankSnapshot {
+		clock := newFakeClock()
+		bank := NewBankService()
+		bank.SetClock(clock.Now)
+		if err := bank.Seed(opts); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return bank.SnapshotAll()
+	}
+
+	first := seeded(opts)
+	if len(first.Users) != 5 || len(first.Accounts) != 15 {
+		t.Fatalf("expected 5 users and 15 accounts, got %d and %d", len(first.Users), len(first.Accounts))
+	}
+	if second := seeded(opts); !reflect.DeepEqual(first, second) {
+		t.Errorf("expected identical state for the same seed")
+	}
+
+	opts.Seed = 7
+	if other := seeded(opts); reflect.DeepEqual(first, other) {
+		t.Errorf("expected different state for a different seed")
+	}
+}
+
+// TestSeedInvalidOptions ensures negative counts and balances are rejected.
+func TestSeedInvalidOptions(t *testing.T) {
+	bank := NewBankService()
+
+	if err := bank.Seed(SeedOptions{Users: -1}); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("expected ErrInvalidAmount, got %v", err)
+	}
+}
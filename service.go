@@ -3,20 +3,138 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
-// Predefined errors for handling failures.
+// Predefined errors for handling failures. Each carries a stable code for
+// integrators, see CodeOf.
 var (
-	ErrNegativeDeposit      = errors.New("initial deposit cannot be negative")
-	ErrAccountNotExist      = errors.New("account does not exist")
-	ErrInvalidAmount        = errors.New("amount must be positive")
-	ErrInsufficientBalance  = errors.New("insufficient balance")
-	ErrUnauthorizedAccess   = errors.New("unauthorized access to account")
-	ErrCurrencyMismatch     = errors.New("currency mismatch between accounts")
-	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrNegativeDeposit          = newError("negative_deposit", "initial deposit cannot be negative")
+	ErrAccountNotExist          = newError("account_not_found", "account does not exist")
+	ErrUserNotExist             = newError("user_not_found", "user does not exist")
+	ErrInvalidAmount            = newError("invalid_amount", "amount must be positive")
+	ErrInsufficientBalance      = newError("insufficient_balance", "insufficient balance")
+	ErrUnauthorizedAccess       = newError("unauthorized", "unauthorized access to account")
+	ErrCurrencyMismatch         = newError("currency_mismatch", "currency mismatch between accounts")
+	ErrExchangeRateNotFound     = newError("exchange_rate_not_found", "exchange rate not found")
+	ErrInvalidRole              = newError("invalid_role", "invalid user role")
+	ErrLockTimeout              = newError("lock_timeout", "timed out waiting for account lock")
+	ErrAuditChainBroken         = newError("audit_chain_broken", "transaction history audit chain is broken")
+	ErrTransactionLimitExceeded = newError("transaction_limit_exceeded", "transaction limit exceeded for role")
+	ErrAccountFrozen            = newError("account_frozen", "account is frozen")
+	ErrLabelTooLong             = newError("label_too_long", "account label is too long")
+	ErrMemoTooLong              = newError("memo_too_long", "transfer memo is too long")
+	ErrUnknownCurrency          = newError("unknown_currency", "unknown currency")
+	ErrAccountIDInUse           = newError("account_id_in_use", "account ID already in use")
+	ErrUserInactive             = newError("user_inactive", "user is deactivated")
+	ErrAccountLimitReached      = newError("account_limit_reached", "maximum number of accounts per user reached")
+	ErrStaleExchangeRate        = newError("stale_exchange_rate", "exchange rate is stale")
+	ErrRateOutOfBounds          = newError("rate_out_of_bounds", "exchange rate outside configured bounds")
+	ErrMaxBalanceExceeded       = newError("max_balance_exceeded", "maximum account balance exceeded")
+	ErrZeroOpening              = newError("zero_opening_deposit", "opening deposit must be positive for this currency")
+	ErrUnknownOperation         = newError("unknown_operation", "unknown operation")
+	ErrAccountClosed            = newError("account_closed", "account is closed")
+	ErrAccountNotEmpty          = newError("account_not_empty", "account balance must be zero")
+	ErrOrphanedAccount          = newError("orphaned_account", "account owner does not exist")
+	ErrOwnerMismatch            = newError("owner_mismatch", "account is listed under a user who does not own it")
+	ErrInvalidBalance           = newError("invalid_balance", "balance is not a finite number")
+	ErrHoldNotFound             = newError("hold_not_found", "hold does not exist")
+	ErrBalanceOverflow          = newError("balance_overflow", "balance would overflow")
+	ErrUnknownCategory          = newError("unknown_category", "transaction category is not allowed")
+	ErrVersionConflict          = newError("version_conflict", "account was modified concurrently")
+	ErrTransactionNotFound      = newError("transaction_not_found", "transaction does not exist")
+	ErrRefundExceedsOriginal    = newError("refund_exceeds_original", "refunds exceed the original transaction amount")
+	ErrInvalidRate              = newError("invalid_rate", "exchange rate must be a positive finite number")
+	ErrUnbreakableAmount        = newError("unbreakable_amount", "amount can't be made from the available denominations")
+	ErrTransferRequestNotFound  = newError("transfer_request_not_found", "transfer request does not exist")
+	ErrExternalRefInUse         = newError("external_ref_in_use", "external reference is already assigned to another account")
+	ErrInvalidPath              = newError("invalid_path", "transfer path must list at least two distinct accounts")
+	ErrRateLimited              = newError("rate_limited", "too many operations on account, try again later")
+	ErrInvalidPrecision         = newError("invalid_precision", "currency precision must be between 0 and 18 decimals")
+	ErrActiveHolds              = newError("active_holds", "account has active holds")
+	ErrInvalidAllocation        = newError("invalid_allocation", "allocation ratios must be positive, distinct per account and sum to 1")
+
+	ErrScheduledTransferNotFound = newError("scheduled_transfer_not_found", "scheduled transfer does not exist")
+)
+
+// codedError is a predefined error with a stable machine-readable code.
+type codedError struct {
+	code string
+	msg  string
+}
+
+// newError creates a predefined error with the given code and message.
+func newError(code, msg string) error {
+	return &codedError{code: code, msg: msg}
+}
+
+// Error returns the error's message.
+func (e *codedError) Error() string {
+	return e.msg
+}
+
+// ErrorCode returns the error's stable machine-readable code, e.g. "insufficient_balance".
+func (e *codedError) ErrorCode() string {
+	return e.code
+}
+
+// CodeOf returns the code of the predefined error err wraps, or "unknown" if none.
+func CodeOf(err error) string {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return "unknown"
+}
+
+// Markers for a BankError that isn't tied to a specific user or account.
+const (
+	noUser    = -1
+	noAccount = -1
 )
 
+// BankError wraps a predefined error with the user and account it concerns, so
+// callers can tell which entity caused a failure. errors.Is still matches the
+// underlying error.
+type BankError struct {
+	UserID    int // User involved, or -1 if none
+	AccountID int // Account involved, or -1 if none
+	Err       error
+}
+
+// Error formats the error with the IDs that are set.
+func (e *BankError) Error() string {
+	switch {
+	case e.UserID == noUser:
+		return fmt.Sprintf("account %d: %v", e.AccountID, e.Err)
+	case e.AccountID == noAccount:
+		return fmt.Sprintf("user %d: %v", e.UserID, e.Err)
+	}
+	return fmt.Sprintf("user %d, account %d: %v", e.UserID, e.AccountID, e.Err)
+}
+
+// Unwrap returns the underlying predefined error.
+func (e *BankError) Unwrap() error {
+	return e.Err
+}
+
+// accountError wraps err with the account it concerns.
+func accountError(accountID int, err error) error {
+	return &BankError{UserID: noUser, AccountID: accountID, Err: err}
+}
+
+// userError wraps err with the user it concerns.
+func userError(userID int, err error) error {
+	return &BankError{UserID: userID, AccountID: noAccount, Err: err}
+}
+
 // Supported currencies
 const (
 	USD = "USD"
@@ -24,20 +142,40 @@ const (
 	GBP = "GBP"
 )
 
+// Role determines what a user is allowed to do.
+type Role string
+
 // User roles
 const (
-	Customer        = "customer"
-	Banker          = "banker"
-	Teller          = "teller"
-	ExchangeManager = "exchange_manager"
+	Customer        Role = "customer"
+	Banker          Role = "banker"
+	Teller          Role = "teller"
+	ExchangeManager Role = "exchange_manager"
+)
+
+// Operation identifies a kind of mutating account operation.
+type Operation string
+
+// Account operations
+const (
+	OpDeposit  Operation = "deposit"
+	OpWithdraw Operation = "withdraw"
+	OpTransfer Operation = "transfer"
+	OpExchange Operation = "exchange"
 )
 
 // User represents a bank user with multiple accounts and optional backup fund usage.
 type User struct {
-	ID             int
-	Role           string
-	Accounts       []int // List of account IDs belonging to the user
-	UseBackupFunds bool  // If true, withdraw from other accounts when needed
+	ID                int
+	Role              Role
+	Accounts          []int // List of account IDs belonging to the user
+	UseBackupFunds    bool  // If true, withdraw from other accounts when needed
+	Deactivated       bool  // Deactivated users can't move funds out of accounts
+	MaxBackupAccounts int   // Backup accounts a single withdrawal may tap, zero for no limit
+
+	// PartialWithdrawalAllowed lets a withdrawal that backup funds can't fully cover
+	// succeed with whatever was available instead of failing.
+	PartialWithdrawalAllowed bool
 }
 
 // Account stores balance and currency information.
@@ -45,29 +183,154 @@ type Account struct {
 	balance  float64
 	currency string
 	mutex    sync.RWMutex
-	ownerID  int // User ID of the account owner
+	queue    fifoLock      // Orders contended transfers when fair locking is enabled
+	ownerID  int           // User ID of the account owner
+	history  []Transaction // Transaction history, oldest first
+	frozen   bool          // Frozen accounts accept deposits but no debits
+	closed   bool          // Closed accounts accept no further transactions
+	label    string        // Free-form name chosen by the owner
+
+	held        float64        // Funds reserved by holds, not available for debits
+	activeHolds map[int64]hold // Holds by ID
+
+	maxBalance     float64            // Cap on the balance deposits can reach, zero for no cap
+	lowBalance     float64            // Balance below which the owner is alerted, zero for no alerts
+	backupPriority int                // Higher priorities are drained first as backup funds
+	buckets        map[string]float64 // Balances in other currencies, nil unless multi-currency
+	refunded       map[int64]float64  // Amount refunded so far by original payout transaction ID
+	archivedHash   string             // Hash of the last transaction evicted from history
+
+	overdraftLimit  float64   // How far below zero withdrawals may take the balance
+	overdraftRate   float64   // Daily interest rate charged on a negative balance
+	interestRate    float64   // Annual interest rate paid on a positive balance
+	compounding     Frequency // How often interest is compounded into the balance
+	lastAccrual     time.Time // Last compounding boundary interest was accrued up to
+	lastActivity    time.Time // Last customer-initiated transaction or account opening
+	lastDormancyFee time.Time // When a dormancy fee was last charged
+	deferPosting    bool      // Accrued interest awaits PostInterest instead of being recorded
+	unposted        float64   // Interest accrued into the balance but not yet posted
+
+	// committed holds the float64 bits of the balance as of the last recorded
+	// transaction, so it can be read without taking the account lock.
+	committed atomic.Uint64
+
+	version int64 // Incremented by every recorded transaction
+
+	rateLimit  int       // Mutating operations allowed per minute, zero for no limit
+	tokens     float64   // Operations the rate limit currently allows
+	lastRefill time.Time // When tokens were last replenished
+
+	watchers    map[int64]*watcher // Balance change subscriptions by watch ID
+	nextWatchID int64
+}
+
+// available returns the amount that can be debited from the account: its balance
+// less any funds on hold. The caller must hold the account lock.
+func (a *Account) available() float64 {
+	return a.balance - a.held
+}
+
+// checkCredit verifies amount can be added to the balance without exceeding the
+// account's maximum balance or overflowing. The caller must hold the account lock.
+func (a *Account) checkCredit(accountID int, amount float64) error {
+	if a.maxBalance > 0 && a.balance+amount > a.maxBalance {
+		return accountError(accountID, ErrMaxBalanceExceeded)
+	}
+	if math.IsInf(a.balance+amount, 0) {
+		return accountError(accountID, ErrBalanceOverflow)
+	}
+	return nil
 }
 
 // BankService manages users, accounts, and currency exchange rates.
 type BankService struct {
-	accounts      map[int]*Account
-	users         map[int]*User
-	exchangeRates map[string]float64 // Store exchange rates (e.g., "USD:EUR" -> 0.85)
-	nextAccountID int
-	mutex         sync.Mutex
+	accounts          map[int]*Account
+	users             map[int]*User
+	exchangeRates     map[string]float64             // Store exchange rates (e.g., "USD:EUR" -> 0.85)
+	roleLimits        map[Role]map[Operation]float64 // Per-transaction caps by role and operation
+	maxAccounts       int                            // Accounts allowed per non-Banker user, zero for no limit
+	backupOrder       BackupOrder                    // Order in which backup accounts are drained
+	rateUpdated       map[string]time.Time           // When each exchange rate was last set
+	rateBounds        map[string][2]float64          // Sanity band [min, max] for each exchange rate
+	positiveOpening   map[string]bool                // Currencies that require a positive opening deposit
+	dormancyFees      map[string]dormancyFee         // Inactivity fees by currency
+	feePool           map[string]float64             // Collected fees by currency
+	feeAccounts       map[string]int                 // Account receiving collected fees by currency
+	externalRefs      map[string]int                 // Account by external reference, e.g. an IBAN
+	accountRefs       map[int]string                 // External reference by account
+	closedFallbacks   map[int]int                    // Account receiving deposits for a closed account
+	holdAccounts      map[int64]int                  // Account each open hold was placed on
+	scheduled         map[int64]*ScheduledTransfer   // Pending scheduled transfers by ID
+	approvals         map[int64]*transferRequest     // Transfers awaiting approval by request ID
+	holidays          map[string]bool                // Bank holidays by date, see SetHolidays
+	categories        map[string]bool                // Allowed transaction categories, nil to allow any
+	notifyHandlers    map[int]func(Notification)     // Notification handler by user ID
+	currencies        map[string]CurrencyInfo        // Display and rounding settings by currency code
+	nextAccountID     int
+	nextHoldID        int64
+	holdExpiry        time.Duration // How long holds last, zero for no expiry
+	nextScheduleID    int64
+	nextRequestID     int64
+	approvalThreshold float64       // Transfers above this need approval, zero for none
+	lockTimeout       time.Duration // Zero means wait indefinitely for account locks
+	historyLimit      atomic.Int64  // Transactions retained per account, zero for no limit
+	nextTxID          atomic.Int64
+	strictInvariants  atomic.Bool // Panic on internal consistency violations
+	noBalanceCache    atomic.Bool // Read balances under the account lock
+	fairLocking       atomic.Bool // Queue transfers for their accounts in arrival order
+	clock             func() time.Time
+	onArchive         atomic.Pointer[func(Transaction)] // Called with transactions evicted from history
+	subscribers       []func(Event)
+	rateProvider      RateProvider  // Consulted for rates missing from exchangeRates
+	rateStaleness     time.Duration // Maximum age of a usable exchange rate, zero for no limit
+	staleRateWarnOnly bool          // Emit an event instead of failing on stale rates
+	freezeBypass      bool          // Bankers may withdraw from frozen accounts
+	slowThreshold     time.Duration
+	onSlow            func(op string, d time.Duration)
+	journal           io.Writer // Receives records of executed commands, nil to disable
+	journalSeq        int64
+	journalErr        error      // First failed journal write, see SetJournal
+	journalMutex      sync.Mutex // Serializes journal writes, separate from the bank lock
+	mutex             sync.Mutex
 }
 
 // NewBankService initializes a new BankService instance.
 func NewBankService() *BankService {
 	return &BankService{
-		accounts:      make(map[int]*Account),
-		users:         make(map[int]*User),
-		exchangeRates: make(map[string]float64),
+		accounts:        make(map[int]*Account),
+		users:           make(map[int]*User),
+		exchangeRates:   make(map[string]float64),
+		rateUpdated:     make(map[string]time.Time),
+		rateBounds:      make(map[string][2]float64),
+		positiveOpening: make(map[string]bool),
+		dormancyFees:    make(map[string]dormancyFee),
+		feePool:         make(map[string]float64),
+		feeAccounts:     make(map[string]int),
+		externalRefs:    make(map[string]int),
+		accountRefs:     make(map[int]string),
+		closedFallbacks: make(map[int]int),
+		holdAccounts:    make(map[int64]int),
+		scheduled:       make(map[int64]*ScheduledTransfer),
+		approvals:       make(map[int64]*transferRequest),
+		notifyHandlers:  make(map[int]func(Notification)),
+		roleLimits:      make(map[Role]map[Operation]float64),
+		currencies:      defaultCurrencies(),
+		clock:           time.Now,
 	}
 }
 
+// SetClock replaces the time source used for timestamps and interest accrual.
+// It is meant for tests and must be called before the service is used concurrently.
+func (b *BankService) SetClock(clock func() time.Time) {
+	b.clock = clock
+}
+
 // CreateUser creates a new user with a specific role and backup fund usage setting.
-func (b *BankService) CreateUser(userID int, role string, useBackupFunds bool) {
+func (b *BankService) CreateUser(userID int, role Role, useBackupFunds bool) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
@@ -77,221 +340,1390 @@ func (b *BankService) CreateUser(userID int, role string, useBackupFunds bool) {
 		UseBackupFunds: useBackupFunds,
 	}
 	fmt.Printf("Created user %d with role %s\n", userID, role)
+	return nil
 }
 
-// CreateAccount creates an account for a user with an initial deposit and currency.
-func (b *BankService) CreateAccount(userID int, initialDeposit float64, currency string) (int, error) {
-	if initialDeposit < 0 {
-		return 0, ErrNegativeDeposit
+// ChangeRole changes the role of a user. Only a Banker can change roles.
+func (b *BankService) ChangeRole(requesterID, targetID int, newRole Role) error {
+	if !ValidRole(newRole) {
+		return ErrInvalidRole
 	}
 
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	accountID := b.nextAccountID
-	b.accounts[accountID] = &Account{
-		balance:  initialDeposit,
-		currency: currency,
-		ownerID:  userID,
+	requester, exists := b.users[requesterID]
+	if !exists || requester.Role != Banker {
+		return userError(requesterID, ErrUnauthorizedAccess)
 	}
-	b.nextAccountID++
 
-	b.users[userID].Accounts = append(b.users[userID].Accounts, accountID)
-	fmt.Printf("Created account %d for user %d with %s %.2f\n", accountID, userID, currency, initialDeposit)
-	return accountID, nil
+	target, exists := b.users[targetID]
+	if !exists {
+		return userError(targetID, ErrUserNotExist)
+	}
+
+	target.Role = newRole
+	fmt.Printf("User %d changed role of user %d to %s\n", requesterID, targetID, newRole)
+	return nil
 }
 
-// CheckPermissions verifies if the user can access the account.
-func (b *BankService) CheckPermissions(userID, accountID int) error {
-	account, exists := b.accounts[accountID]
+// DeactivateUser soft-deletes a user so they can no longer move funds out of any
+// account. Deposits to their accounts are still accepted. Only a Banker can
+// deactivate users.
+func (b *BankService) DeactivateUser(requesterID, targetID int) error {
+	return b.setDeactivated(requesterID, targetID, true)
+}
+
+// ReactivateUser restores a deactivated user. Only a Banker can reactivate users.
+func (b *BankService) ReactivateUser(requesterID, targetID int) error {
+	return b.setDeactivated(requesterID, targetID, false)
+}
+
+// setDeactivated updates the active status of a user on behalf of a Banker.
+func (b *BankService) setDeactivated(requesterID, targetID int, deactivated bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	requester, exists := b.users[requesterID]
+	if !exists || requester.Role != Banker {
+		return userError(requesterID, ErrUnauthorizedAccess)
+	}
+
+	target, exists := b.users[targetID]
 	if !exists {
-		return ErrAccountNotExist
+		return userError(targetID, ErrUserNotExist)
 	}
 
-	user := b.users[userID]
-	if user.Role == Banker || account.ownerID == userID {
-		return nil // Access granted
+	target.Deactivated = deactivated
+	fmt.Printf("User %d set user %d deactivated: %t\n", requesterID, targetID, deactivated)
+	return nil
+}
+
+// SetMaxBackupAccounts limits how many backup accounts a single withdrawal by the
+// user may tap. A limit of zero removes the restriction.
+func (b *BankService) SetMaxBackupAccounts(userID int, n int) error {
+	if n < 0 {
+		return ErrInvalidAmount
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	user, exists := b.users[userID]
+	if !exists {
+		return userError(userID, ErrUserNotExist)
 	}
-	return ErrUnauthorizedAccess
+
+	user.MaxBackupAccounts = n
+	fmt.Printf("Set maximum backup accounts for user %d: %d\n", userID, n)
+	return nil
 }
 
-// GetBalance retrieves the balance and currency of an account.
-func (b *BankService) GetBalance(userID, accountID int) (float64, string, error) {
-	if err := b.CheckPermissions(userID, accountID); err != nil {
-		return 0, "", err
+// SetPartialWithdrawalAllowed sets whether the user's withdrawals may succeed with
+// less than the requested amount when backup funds run out.
+func (b *BankService) SetPartialWithdrawalAllowed(userID int, allowed bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	user, exists := b.users[userID]
+	if !exists {
+		return userError(userID, ErrUserNotExist)
 	}
 
-	account := b.accounts[accountID]
-	account.mutex.RLock()
-	defer account.mutex.RUnlock()
+	user.PartialWithdrawalAllowed = allowed
+	fmt.Printf("Set partial withdrawals for user %d: %t\n", userID, allowed)
+	return nil
+}
 
-	return account.balance, account.currency, nil
+// ValidRole reports whether role is one of the defined user roles.
+func ValidRole(role Role) bool {
+	switch role {
+	case Customer, Banker, Teller, ExchangeManager:
+		return true
+	}
+	return false
 }
 
-// Deposit adds funds to the specified account.
-func (b *BankService) Deposit(userID, accountID int, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
+// CreateAccount creates an account for a user with an initial deposit and currency.
+func (b *BankService) CreateAccount(userID int, initialDeposit float64, currency string) (int, error) {
+	if initialDeposit < 0 {
+		return 0, ErrNegativeDeposit
 	}
-	if err := b.CheckPermissions(userID, accountID); err != nil {
-		return err
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.checkAccountLimit(userID, 1); err != nil {
+		return 0, err
+	}
+	if err := b.checkOpeningDeposit(initialDeposit, currency); err != nil {
+		return 0, err
 	}
 
-	account := b.accounts[accountID]
-	account.mutex.Lock()
-	defer account.mutex.Unlock()
+	accountID := b.nextAccountID
+	b.openAccount(accountID, userID, initialDeposit, currency)
+	return accountID, nil
+}
 
-	account.balance += amount
-	fmt.Printf("User %d deposited %.2f to account %d\n", userID, amount, accountID)
-	return nil
+// ReserveAccountID allocates an account ID without creating the account, so it can
+// be registered elsewhere before calling CreateAccountWithID.
+func (b *BankService) ReserveAccountID() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	accountID := b.nextAccountID
+	b.nextAccountID++
+	return accountID
 }
 
-// Withdraw tries to withdraw from the specified account, with optional backup funds usage.
-func (b *BankService) Withdraw(userID, accountID int, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
+// CreateAccountWithID creates an account under a specific ID, typically one obtained
+// from ReserveAccountID.
+func (b *BankService) CreateAccountWithID(id, userID int, initialDeposit float64, currency string) error {
+	if initialDeposit < 0 {
+		return ErrNegativeDeposit
 	}
-	if err := b.CheckPermissions(userID, accountID); err != nil {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.accounts[id]; exists {
+		return accountError(id, ErrAccountIDInUse)
+	}
+	if err := b.checkAccountLimit(userID, 1); err != nil {
+		return err
+	}
+	if err := b.checkOpeningDeposit(initialDeposit, currency); err != nil {
 		return err
 	}
+	b.openAccount(id, userID, initialDeposit, currency)
+	return nil
+}
 
-	account := b.accounts[accountID]
-	account.mutex.Lock()
-	defer account.mutex.Unlock()
+// SetMaxAccountsPerUser limits how many accounts a user can own. Bankers are exempt.
+// A limit of zero removes the restriction.
+func (b *BankService) SetMaxAccountsPerUser(n int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	if account.balance >= amount {
-		account.balance -= amount
-		fmt.Printf("User %d withdrew %.2f from account %d\n", userID, amount, accountID)
-		return nil
+	b.maxAccounts = n
+	fmt.Printf("Set maximum accounts per user: %d\n", n)
+}
+
+// SetRequirePositiveOpening controls whether accounts in the currency must be
+// opened with a positive deposit rather than zero.
+func (b *BankService) SetRequirePositiveOpening(currency string, required bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.positiveOpening[currency] = required
+	fmt.Printf("Set positive opening deposit required for %s: %t\n", currency, required)
+}
+
+// checkOpeningDeposit verifies the opening deposit satisfies the currency's policy.
+// The caller must hold the bank lock.
+func (b *BankService) checkOpeningDeposit(initialDeposit float64, currency string) error {
+	if initialDeposit == 0 && b.positiveOpening[currency] {
+		return ErrZeroOpening
 	}
+	return nil
+}
 
-	// Try backup funds if allowed.
-	user := b.users[userID]
-	if user.UseBackupFunds {
-		remaining := amount - account.balance
-		account.balance = 0
-		fmt.Printf("User %d withdrew %.2f from primary account %d, remaining %.2f\n", userID, amount-account.balance, accountID, remaining)
-		return b.withdrawFromOtherAccounts(userID, accountID, remaining)
+// checkAccountLimit verifies the user can open count more accounts. The caller must
+// hold the bank lock.
+func (b *BankService) checkAccountLimit(userID, count int) error {
+	user, exists := b.users[userID]
+	if !exists {
+		return userError(userID, ErrUserNotExist)
 	}
+	if b.maxAccounts > 0 && user.Role != Banker && len(user.Accounts)+count > b.maxAccounts {
+		return userError(userID, ErrAccountLimitReached)
+	}
+	return nil
+}
 
-	return ErrInsufficientBalance
+// AccountSpec describes an account to be created by CreateAccounts.
+type AccountSpec struct {
+	InitialDeposit float64
+	Currency       string
 }
 
-// withdrawFromOtherAccounts withdraws the remaining amount from backup accounts.
-func (b *BankService) withdrawFromOtherAccounts(userID, excludeAccountID int, amount float64) error {
-	for _, accID := range b.users[userID].Accounts {
-		if accID == excludeAccountID {
-			continue // Skip the original account
+// CreateAccounts creates several accounts for a user in one step and returns their
+// IDs in order. If any spec is invalid, no accounts are created.
+func (b *BankService) CreateAccounts(userID int, specs []AccountSpec) ([]int, error) {
+	for _, spec := range specs {
+		if spec.InitialDeposit < 0 {
+			return nil, ErrNegativeDeposit
 		}
+	}
 
-		account := b.accounts[accID]
-		account.mutex.Lock()
-		if account.balance >= amount {
-			account.balance -= amount
-			account.mutex.Unlock()
-			fmt.Printf("Withdrew %.2f from backup account %d\n", amount, accID)
-			return nil
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.checkAccountLimit(userID, len(specs)); err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		if _, known := b.currencies[spec.Currency]; !known {
+			return nil, ErrUnknownCurrency
+		}
+		if err := b.checkOpeningDeposit(spec.InitialDeposit, spec.Currency); err != nil {
+			return nil, err
 		}
-		amount -= account.balance
-		account.balance = 0
-		account.mutex.Unlock()
-		fmt.Printf("Used all funds from backup account %d, remaining %.2f\n", accID, amount)
 	}
 
-	return ErrInsufficientBalance
+	accountIDs := make([]int, len(specs))
+	for i, spec := range specs {
+		accountIDs[i] = b.nextAccountID
+		b.openAccount(accountIDs[i], userID, spec.InitialDeposit, spec.Currency)
+	}
+	return accountIDs, nil
 }
 
-// Transfer transfers funds between two accounts with the same currency.
-func (b *BankService) Transfer(fromID, toID int, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
-	}
+// openAccount creates an account under the given ID and assigns it to the user.
+// The caller must hold the bank lock.
+func (b *BankService) openAccount(accountID, userID int, initialDeposit float64, currency string) {
+	account := &Account{
+		balance:  initialDeposit,
+		currency: currency,
+		ownerID:  userID,
 
-	fromAccount, err := b.getAccount(fromID)
-	if err != nil {
-		return err
+		lastAccrual:  b.clock(),
+		lastActivity: b.clock(),
+	}
+	if initialDeposit > 0 {
+		b.recordTransaction(accountID, account, TxDeposit, initialDeposit, noCounterparty)
+	}
+	b.accounts[accountID] = account
+	if accountID >= b.nextAccountID {
+		b.nextAccountID = accountID + 1 // Never hand out an ID that is already taken
 	}
 
-	toAccount, err := b.getAccount(toID)
-	if err != nil {
+	// Keep the user's accounts sorted by ID so iteration over them is deterministic.
+	user := b.users[userID]
+	i, _ := slices.BinarySearch(user.Accounts, accountID)
+	user.Accounts = slices.Insert(user.Accounts, i, accountID)
+	fmt.Printf("Created account %d for user %d with %s %.2f\n", accountID, userID, currency, initialDeposit)
+}
+
+// TransferOwnership moves an account to a different user. Only the current owner
+// or a Banker can reassign an account, and the new owner's account limit applies.
+func (b *BankService) TransferOwnership(requesterID, accountID, newOwnerID int) error {
+	if err := b.CheckPermissions(requesterID, accountID); err != nil {
 		return err
 	}
 
-	if fromAccount.currency != toAccount.currency {
-		return ErrCurrencyMismatch
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.checkAccountLimit(newOwnerID, 1); err != nil {
+		return err
 	}
 
-	fromAccount.mutex.Lock()
-	defer fromAccount.mutex.Unlock()
+	account := b.accounts[accountID]
+	account.mutex.Lock()
+	oldOwnerID := account.ownerID
+	account.ownerID = newOwnerID
+	account.mutex.Unlock()
 
-	if fromAccount.balance < amount {
-		return ErrInsufficientBalance
+	oldOwner := b.users[oldOwnerID]
+	if i, found := slices.BinarySearch(oldOwner.Accounts, accountID); found {
+		oldOwner.Accounts = slices.Delete(oldOwner.Accounts, i, i+1)
 	}
+	newOwner := b.users[newOwnerID]
+	i, _ := slices.BinarySearch(newOwner.Accounts, accountID)
+	newOwner.Accounts = slices.Insert(newOwner.Accounts, i, accountID)
 
-	toAccount.mutex.Lock()
-	defer toAccount.mutex.Unlock()
-
-	fromAccount.balance -= amount
-	toAccount.balance += amount
-	fmt.Printf("Transferred %.2f from account %d to account %d\n", amount, fromID, toID)
+	fmt.Printf("User %d transferred account %d from user %d to user %d\n", requesterID, accountID, oldOwnerID, newOwnerID)
 	return nil
 }
 
-// SetExchangeRate sets the exchange rate between two currencies.
-func (b *BankService) SetExchangeRate(from, to string, rate float64) {
+// CheckPermissions verifies if the user can access the account.
+func (b *BankService) CheckPermissions(userID, accountID int) error {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	key := from + ":" + to
-	b.exchangeRates[key] = rate
-	fmt.Printf("Set exchange rate %s -> %s: %.2f\n", from, to, rate)
-}
+	account, exists := b.accounts[accountID]
+	if !exists {
+		return accountError(accountID, ErrAccountNotExist)
+	}
 
-// ExchangeCurrency exchanges an amount from one currency to another.
-func (b *BankService) ExchangeCurrency(userID, fromID, toID int, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
+	user, exists := b.users[userID]
+	if !exists {
+		return userError(userID, ErrUserNotExist)
 	}
-	if err := b.CheckPermissions(userID, fromID); err != nil {
-		return err
+	if user.Role == Banker || account.ownerID == userID {
+		return nil // Access granted
 	}
-	if err := b.CheckPermissions(userID, toID); err != nil {
+	return &BankError{UserID: userID, AccountID: accountID, Err: ErrUnauthorizedAccess}
+}
+
+// checkDebitPermissions verifies the user can access the account and is still
+// active. It governs every operation that moves funds out of an account.
+func (b *BankService) checkDebitPermissions(userID, accountID int) error {
+	if err := b.CheckPermissions(userID, accountID); err != nil {
 		return err
 	}
+	return b.checkActive(userID)
+}
 
-	fromAccount := b.accounts[fromID]
-	toAccount := b.accounts[toID]
+// checkActive verifies the user has not been deactivated.
+func (b *BankService) checkActive(userID int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	key := fromAccount.currency + ":" + toAccount.currency
-	rate, exists := b.exchangeRates[key]
+	user, exists := b.users[userID]
 	if !exists {
-		return ErrExchangeRateNotFound
+		return userError(userID, ErrUserNotExist)
+	}
+	if user.Deactivated {
+		return userError(userID, ErrUserInactive)
+	}
+	return nil
+}
+
+// CheckViewPermissions verifies if the user can view the account. In addition to
+// the owner and Bankers, Tellers can view any account to assist customers.
+func (b *BankService) CheckViewPermissions(userID, accountID int) error {
+	err := b.CheckPermissions(userID, accountID)
+	if !errors.Is(err, ErrUnauthorizedAccess) {
+		return err
 	}
 
-	fromAccount.mutex.Lock()
-	defer fromAccount.mutex.Unlock()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	if fromAccount.balance < amount {
-		return ErrInsufficientBalance
+	if b.users[userID].Role == Teller {
+		return nil // Read-only access granted
 	}
+	return err
+}
 
-	toAccount.mutex.Lock()
-	defer toAccount.mutex.Unlock()
+// SetRoleTransactionLimit caps the amount a user with the given role can move in a
+// single operation. Roles without a configured limit are uncapped.
+func (b *BankService) SetRoleTransactionLimit(role Role, op Operation, max float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	fromAccount.balance -= amount
-	toAccount.balance += amount * rate
-	fmt.Printf("Exchanged %.2f %s to %.2f %s\n", amount, fromAccount.currency, amount*rate, toAccount.currency)
-	return nil
+	if b.roleLimits[role] == nil {
+		b.roleLimits[role] = make(map[Operation]float64)
+	}
+	b.roleLimits[role][op] = max
+	fmt.Printf("Set %s limit for role %s: %.2f\n", op, role, max)
 }
 
-// getAccount retrieves an account by its ID.
-func (b *BankService) getAccount(accountID int) (*Account, error) {
+// checkTransactionLimit verifies the amount is within the user's role limit for the operation.
+func (b *BankService) checkTransactionLimit(userID int, op Operation, amount float64) error {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	account, exists := b.accounts[accountID]
+	user, exists := b.users[userID]
 	if !exists {
-		return nil, ErrAccountNotExist
+		return userError(userID, ErrUserNotExist)
 	}
-	return account, nil
+
+	max, limited := b.roleLimits[user.Role][op]
+	if limited && amount > max {
+		return ErrTransactionLimitExceeded
+	}
+	return nil
+}
+
+// FreezeAccount freezes an account so that no funds can be debited from it.
+// Only a Banker or Teller can freeze accounts.
+func (b *BankService) FreezeAccount(requesterID, accountID int) error {
+	return b.setFrozen(requesterID, accountID, true)
+}
+
+// UnfreezeAccount lifts a freeze from an account. Only a Banker or Teller can
+// unfreeze accounts.
+func (b *BankService) UnfreezeAccount(requesterID, accountID int) error {
+	return b.setFrozen(requesterID, accountID, false)
+}
+
+// SetBankerBypassFreeze sets whether Bankers can withdraw from frozen accounts. By
+// default the freeze applies to everyone.
+func (b *BankService) SetBankerBypassFreeze(bypass bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.freezeBypass = bypass
+}
+
+// bypassesFreeze reports whether the user may withdraw from frozen accounts.
+func (b *BankService) bypassesFreeze(userID int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	user, exists := b.users[userID]
+	return b.freezeBypass && exists && user.Role == Banker
+}
+
+// setFrozen updates the frozen status of an account on behalf of a Banker or Teller.
+func (b *BankService) setFrozen(requesterID, accountID int, frozen bool) error {
+	if err := b.checkStaffRole(requesterID); err != nil {
+		return err
+	}
+
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.Lock()
+	account.frozen = frozen
+	account.mutex.Unlock()
+
+	eventType := EventAccountUnfrozen
+	if frozen {
+		eventType = EventAccountFrozen
+	}
+	fmt.Printf("User %d set account %d frozen: %t\n", requesterID, accountID, frozen)
+	b.emit(Event{Type: eventType, AccountID: accountID, UserID: requesterID})
+	if frozen {
+		b.notify(frozenNotification(accountID))
+	}
+	return nil
+}
+
+// FreezeUserAccounts freezes every account owned by the target user and returns how
+// many accounts were newly frozen. Only a Banker or Teller can freeze accounts.
+func (b *BankService) FreezeUserAccounts(requesterID, targetUserID int) (int, error) {
+	if err := b.checkStaffRole(requesterID); err != nil {
+		return 0, err
+	}
+
+	b.mutex.Lock()
+	target, exists := b.users[targetUserID]
+	if !exists {
+		b.mutex.Unlock()
+		return 0, userError(targetUserID, ErrUserNotExist)
+	}
+	accountIDs := make([]int, len(target.Accounts))
+	copy(accountIDs, target.Accounts)
+	accounts := make([]*Account, len(accountIDs))
+	for i, accID := range accountIDs {
+		accounts[i] = b.accounts[accID]
+	}
+	b.mutex.Unlock()
+
+	var frozen []int
+	for i, account := range accounts {
+		account.mutex.Lock()
+		if !account.frozen {
+			account.frozen = true
+			frozen = append(frozen, accountIDs[i])
+		}
+		account.mutex.Unlock()
+	}
+
+	fmt.Printf("User %d froze %d accounts of user %d\n", requesterID, len(frozen), targetUserID)
+	for _, accID := range frozen {
+		b.emit(Event{Type: EventAccountFrozen, AccountID: accID, UserID: requesterID})
+		b.notify(frozenNotification(accID))
+	}
+	return len(frozen), nil
+}
+
+// checkStaffRole verifies that the user is a Banker or Teller.
+func (b *BankService) checkStaffRole(userID int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	user, exists := b.users[userID]
+	if !exists {
+		return userError(userID, ErrUserNotExist)
+	}
+	if user.Role != Banker && user.Role != Teller {
+		return userError(userID, ErrUnauthorizedAccess)
+	}
+	return nil
+}
+
+// GetBalance retrieves the balance and currency of an account. With the balance
+// cache enabled, the balance is read without locking and reflects the last
+// committed transaction.
+func (b *BankService) GetBalance(userID, accountID int) (float64, string, error) {
+	if err := b.CheckViewPermissions(userID, accountID); err != nil {
+		return 0, "", err
+	}
+
+	account := b.lookupAccount(accountID)
+	if !b.noBalanceCache.Load() {
+		// Read the committed balance atomically so readers never contend with writers.
+		return math.Float64frombits(account.committed.Load()), account.currency, nil
+	}
+
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	return account.balance, account.currency, nil
+}
+
+// SetBalanceCacheEnabled sets whether GetBalance reads the balance each account
+// publishes on every committed transaction instead of taking the account lock.
+// The cache is enabled by default.
+func (b *BankService) SetBalanceCacheEnabled(enabled bool) {
+	b.noBalanceCache.Store(!enabled)
+}
+
+// GetBalanceIn retrieves the balance of an account converted to displayCurrency
+// using the configured exchange rates.
+func (b *BankService) GetBalanceIn(userID, accountID int, displayCurrency string) (float64, error) {
+	balance, currency, err := b.GetBalance(userID, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if currency == displayCurrency {
+		return balance, nil
+	}
+
+	rate, err := b.getExchangeRate(currency, displayCurrency)
+	if err != nil {
+		return 0, err
+	}
+	return balance * rate, nil
+}
+
+// Deposit adds funds to the specified account.
+func (b *BankService) Deposit(userID, accountID int, amount float64) error {
+	_, err := b.deposit(userID, accountID, Transaction{Type: TxDeposit, Amount: amount, Counterparty: noCounterparty})
+	return err
+}
+
+// DepositInCurrency deposits like Deposit, but fails with ErrCurrencyMismatch unless
+// currency is the account's own. Unlike DepositCurrency, it never credits a
+// multi-currency sub-balance.
+func (b *BankService) DepositInCurrency(userID, accountID int, amount float64, currency string) error {
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+	if account := b.lookupAccount(accountID); currency != account.currency {
+		return accountError(accountID, ErrCurrencyMismatch)
+	}
+	return b.Deposit(userID, accountID, amount)
+}
+
+// deposit credits tx.Amount to the account and records tx in its history.
+func (b *BankService) deposit(userID, accountID int, tx Transaction) (Transaction, error) {
+	defer b.observeDuration(OpDeposit, time.Now())
+
+	amount := tx.Amount
+	if amount <= 0 {
+		return tx, ErrInvalidAmount
+	}
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return tx, err
+	}
+	if err := b.checkTransactionLimit(userID, OpDeposit, amount); err != nil {
+		return tx, err
+	}
+	if err := b.throttle(accountID); err != nil {
+		return tx, err
+	}
+	return b.credit(userID, accountID, tx, true)
+}
+
+// credit adds tx.Amount to the account and records tx in its history. A deposit to
+// a closed account is redirected to its fallback account if one is set and redirect
+// is true, and rejected otherwise.
+func (b *BankService) credit(userID, accountID int, tx Transaction, redirect bool) (Transaction, error) {
+	amount := tx.Amount
+
+	b.mutex.Lock()
+	fallbackID, hasFallback := b.closedFallbacks[accountID]
+	b.mutex.Unlock()
+
+	account := b.lookupAccount(accountID)
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return tx, err
+	}
+
+	if account.closed {
+		account.mutex.Unlock()
+		if !redirect || !hasFallback {
+			return tx, accountError(accountID, ErrAccountClosed)
+		}
+		fmt.Printf("Redirecting deposit to closed account %d to account %d\n", accountID, fallbackID)
+		return b.credit(userID, fallbackID, tx, false)
+	}
+
+	if err := account.checkCredit(accountID, amount); err != nil {
+		account.mutex.Unlock()
+		return tx, err
+	}
+
+	account.balance += amount
+	tx = b.record(accountID, account, tx)
+	frozen := account.frozen
+	account.mutex.Unlock()
+	fmt.Printf("User %d deposited %.2f to account %d\n", userID, amount, accountID)
+	b.notify(Notification{Type: NotifyDeposit, AccountID: accountID, Amount: amount, Message: fmt.Sprintf("Deposit of %.2f received", amount)})
+
+	// Deposits into frozen accounts are accepted but flagged for compliance review.
+	if frozen {
+		b.emit(Event{Type: EventFrozenDeposit, AccountID: accountID, UserID: userID, Amount: amount})
+	}
+	return tx, nil
+}
+
+// SetMaxBalance caps the balance deposits can bring an account to. A cap of zero
+// removes the restriction.
+func (b *BankService) SetMaxBalance(accountID int, max float64) error {
+	if max < 0 {
+		return ErrInvalidAmount
+	}
+
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	account.maxBalance = max
+	fmt.Printf("Set maximum balance for account %d: %.2f\n", accountID, max)
+	return nil
+}
+
+// WithdrawalPart is the amount taken from a single account during a withdrawal.
+type WithdrawalPart struct {
+	AccountID int
+	Amount    float64
+}
+
+// WithdrawResult describes which accounts funded a withdrawal and how much came
+// from each.
+type WithdrawResult struct {
+	AccountID int              // Primary account the withdrawal was requested from
+	Parts     []WithdrawalPart // Primary account first, then backups in the order used
+}
+
+// Total returns the amount withdrawn across all accounts.
+func (r WithdrawResult) Total() float64 {
+	total := 0.0
+	for _, part := range r.Parts {
+		total += part.Amount
+	}
+	return total
+}
+
+// UsedBackupFunds reports whether any backup account contributed to the withdrawal.
+func (r WithdrawResult) UsedBackupFunds() bool {
+	for _, part := range r.Parts {
+		if part.AccountID != r.AccountID {
+			return true
+		}
+	}
+	return false
+}
+
+// Withdraw tries to withdraw from the specified account, with optional backup funds usage.
+func (b *BankService) Withdraw(userID, accountID int, amount float64) error {
+	_, err := b.WithdrawDetailed(userID, accountID, amount)
+	return err
+}
+
+// WithdrawDetailed withdraws like Withdraw and reports the accounts that were
+// tapped, including any backup accounts. If backup funds can't cover the amount, no
+// account is debited, unless the user allows partial withdrawals: then it succeeds
+// as long as something can be withdrawn, and the result's Total is the amount
+// actually withdrawn.
+func (b *BankService) WithdrawDetailed(userID, accountID int, amount float64) (WithdrawResult, error) {
+	return b.withdraw(userID, accountID, amount, "", anyVersion, true)
+}
+
+// anyVersion disables the version check in withdraw.
+const anyVersion = -1
+
+// withdraw performs a withdrawal, tagging every resulting transaction with category.
+// Unless expectedVersion is anyVersion, it fails if the primary account's version
+// differs. Without allowPartial, the user's partial withdrawal setting is ignored.
+func (b *BankService) withdraw(userID, accountID int, amount float64, category string, expectedVersion int64, allowPartial bool) (WithdrawResult, error) {
+	defer b.observeDuration(OpWithdraw, time.Now())
+
+	result := WithdrawResult{AccountID: accountID}
+	if amount <= 0 {
+		return result, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, accountID); err != nil {
+		return result, err
+	}
+	if err := b.checkTransactionLimit(userID, OpWithdraw, amount); err != nil {
+		return result, err
+	}
+	if err := b.throttle(accountID); err != nil {
+		return result, err
+	}
+
+	// Resolve backup accounts before locking, so the bank lock is never taken while
+	// holding an account lock.
+	var backups []int
+	var backupAccounts []*Account
+	policy := b.getBackupPolicy(userID)
+	if policy.enabled {
+		var err error
+		if backups, backupAccounts, err = b.backupAccounts(userID, accountID); err != nil {
+			return result, err
+		}
+	}
+	bypassFreeze := b.bypassesFreeze(userID)
+
+	account := b.lookupAccount(accountID)
+	if len(backups) > 0 {
+		// Lock the backups along with the primary account, in ID order, so a withdrawal
+		// they can't cover is refused before any account is debited.
+		unlock, err := lockAll(append([]int{accountID}, backups...), append([]*Account{account}, backupAccounts...), b.getLockTimeout())
+		if err != nil {
+			return result, err
+		}
+		defer unlock()
+	} else {
+		if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+			return result, err
+		}
+		defer account.mutex.Unlock()
+	}
+
+	if expectedVersion != anyVersion && account.version != expectedVersion {
+		return result, accountError(accountID, ErrVersionConflict)
+	}
+	if account.closed {
+		return result, accountError(accountID, ErrAccountClosed)
+	}
+	if account.frozen && !bypassFreeze {
+		return result, accountError(accountID, ErrAccountFrozen)
+	}
+
+	if account.available()+account.overdraftLimit >= amount {
+		account.balance -= amount
+		b.record(accountID, account, withdrawal(amount, category))
+		result.Parts = append(result.Parts, WithdrawalPart{AccountID: accountID, Amount: amount})
+		fmt.Printf("User %d withdrew %.2f from account %d\n", userID, amount, accountID)
+		return result, nil
+	}
+
+	// Try backup funds if allowed. Without any backups, leave the primary untouched.
+	if len(backups) == 0 {
+		return result, ErrInsufficientBalance
+	}
+
+	parts, sources, covered := planBackupWithdrawal(accountID, account, backups, backupAccounts, policy.maxAccounts, amount)
+	result.Parts = parts
+	if !covered {
+		// A partial withdrawal must still withdraw something.
+		if !policy.partial || !allowPartial || len(parts) == 0 {
+			result.Parts = nil
+			return result, ErrInsufficientBalance
+		}
+		fmt.Printf("User %d withdrew %.2f of %.2f requested\n", userID, result.Total(), amount)
+	}
+	for i, part := range parts {
+		sources[i].balance -= part.Amount
+		b.record(part.AccountID, sources[i], withdrawal(part.Amount, category))
+		fmt.Printf("User %d withdrew %.2f from account %d\n", userID, part.Amount, part.AccountID)
+	}
+	return result, nil
+}
+
+// WithdrawIfVersion withdraws like Withdraw only if the account's version still
+// equals expectedVersion, as read from GetAccountInfo, and fails with
+// ErrVersionConflict otherwise.
+func (b *BankService) WithdrawIfVersion(userID, accountID int, amount float64, expectedVersion int64) error {
+	_, err := b.withdraw(userID, accountID, amount, "", expectedVersion, true)
+	return err
+}
+
+// backupPolicy is a snapshot of a user's backup funds settings.
+type backupPolicy struct {
+	enabled     bool
+	maxAccounts int
+	partial     bool
+}
+
+// getBackupPolicy reads the user's backup funds settings under the bank lock.
+func (b *BankService) getBackupPolicy(userID int) backupPolicy {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	user := b.users[userID]
+	return backupPolicy{enabled: user.UseBackupFunds, maxAccounts: user.MaxBackupAccounts, partial: user.PartialWithdrawalAllowed}
+}
+
+// withdrawal returns a withdrawal transaction of amount tagged with category.
+func withdrawal(amount float64, category string) Transaction {
+	return Transaction{Type: TxWithdrawal, Amount: amount, Counterparty: noCounterparty, Category: category}
+}
+
+// planBackupWithdrawal works out how much of amount to take from the primary
+// account's available balance and then from each backup in order, tapping at most
+// maxAccounts backups (zero for no limit). It returns the parts along with the
+// account of each, and whether they cover the whole amount. The caller must hold
+// the locks of all the accounts.
+func planBackupWithdrawal(primaryID int, primary *Account, backups []int, accounts []*Account, maxAccounts int, amount float64) ([]WithdrawalPart, []*Account, bool) {
+	var parts []WithdrawalPart
+	var sources []*Account
+	take := func(accountID int, account *Account) {
+		if drawn := math.Min(account.available(), amount); drawn > 0 {
+			amount -= drawn
+			parts = append(parts, WithdrawalPart{AccountID: accountID, Amount: drawn})
+			sources = append(sources, account)
+		}
+	}
+
+	take(primaryID, primary)
+	tapped := 0
+	for i, accID := range backups {
+		if amount == 0 || (maxAccounts > 0 && tapped == maxAccounts) {
+			break // Covered, or policy forbids tapping more backup accounts
+		}
+		if accounts[i].frozen || accounts[i].available() <= 0 {
+			continue // Frozen accounts can't be debited
+		}
+		take(accID, accounts[i])
+		tapped++
+	}
+	return parts, sources, amount == 0
+}
+
+// BackupOrder determines the order in which backup accounts are drained.
+type BackupOrder int
+
+// Backup account orders
+const (
+	BackupByID         BackupOrder = iota // Ascending account ID
+	BackupByBalanceAsc                    // Smallest balance first, ties by account ID
+)
+
+// SetBackupOrder sets the order in which backup accounts are drained when a
+// withdrawal falls back to backup funds.
+func (b *BankService) SetBackupOrder(order BackupOrder) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.backupOrder = order
+}
+
+// backupAccounts returns the user's accounts other than primaryID that share its
+// currency, by descending backup priority and then in the configured backup order,
+// along with the account of each ID. It fails with ErrLockTimeout if an account
+// can't be read in time.
+func (b *BankService) backupAccounts(userID, primaryID int) ([]int, []*Account, error) {
+	b.mutex.Lock()
+	order := b.backupOrder
+	currency := b.accounts[primaryID].currency
+	var accountIDs []int
+	byID := make(map[int]*Account)
+	for _, accID := range b.users[userID].Accounts {
+		if accID != primaryID && b.accounts[accID].currency == currency {
+			accountIDs = append(accountIDs, accID)
+			byID[accID] = b.accounts[accID]
+		}
+	}
+	timeout := b.lockTimeout
+	b.mutex.Unlock()
+
+	balances := make(map[int]float64, len(accountIDs))
+	priorities := make(map[int]int, len(accountIDs))
+	for _, accID := range accountIDs {
+		account := byID[accID]
+		if err := rlockWithTimeout(&account.mutex, timeout); err != nil {
+			return nil, nil, err
+		}
+		balances[accID] = account.available()
+		priorities[accID] = account.backupPriority
+		account.mutex.RUnlock()
+	}
+
+	if order == BackupByBalanceAsc {
+		sort.SliceStable(accountIDs, func(i, j int) bool {
+			return balances[accountIDs[i]] < balances[accountIDs[j]]
+		})
+	}
+	// Explicit priorities take precedence over the configured order.
+	sort.SliceStable(accountIDs, func(i, j int) bool {
+		return priorities[accountIDs[i]] > priorities[accountIDs[j]]
+	})
+
+	accounts := make([]*Account, len(accountIDs))
+	for i, accID := range accountIDs {
+		accounts[i] = byID[accID]
+	}
+	return accountIDs, accounts, nil
+}
+
+// SetBackupPriority sets the priority of an account as a source of backup funds.
+// Higher priorities are drained first; accounts without one have priority zero.
+func (b *BankService) SetBackupPriority(userID, accountID int, priority int) error {
+	if priority < 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	account.backupPriority = priority
+	fmt.Printf("Set backup priority for account %d: %d\n", accountID, priority)
+	return nil
+}
+
+// Transfer transfers funds between two accounts with the same currency. Like a
+// withdrawal, it may take the source account into its overdraft.
+func (b *BankService) Transfer(fromID, toID int, amount float64) error {
+	_, err := b.transfer(sourceOwner, fromID, toID, amount, "", nil)
+	return err
+}
+
+// TransferWithMemo transfers funds like Transfer and stores the memo in both
+// accounts' history. It returns the ID of the debit transaction.
+func (b *BankService) TransferWithMemo(userID, fromID, toID int, amount float64, memo string) (int64, error) {
+	if utf8.RuneCountInString(memo) > maxMemoLength {
+		return 0, ErrMemoTooLong
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return 0, err
+	}
+	return b.transfer(userID, fromID, toID, amount, memo, nil)
+}
+
+// TransferIfBalance transfers like TransferWithMemo without a memo, but only if the
+// source account's balance still equals expectedFromBalance once the accounts are
+// locked, and fails with ErrVersionConflict otherwise.
+func (b *BankService) TransferIfBalance(userID, fromID, toID int, amount, expectedFromBalance float64) error {
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return err
+	}
+	_, err := b.transfer(userID, fromID, toID, amount, "", &expectedFromBalance)
+	return err
+}
+
+// TransferWithRetry transfers like TransferWithMemo without a memo, retrying up to
+// retries times, backoff apart, while the account locks can't be acquired in time.
+// Other errors are returned immediately.
+func (b *BankService) TransferWithRetry(userID, fromID, toID int, amount float64, retries int, backoff time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		_, err := b.TransferWithMemo(userID, fromID, toID, amount, "")
+		if !errors.Is(err, ErrLockTimeout) || attempt == retries {
+			return err
+		}
+		fmt.Printf("Transfer from account %d timed out on locks, retrying in %s\n", fromID, backoff)
+		time.Sleep(backoff)
+	}
+}
+
+// sourceOwner makes transfer act on behalf of the source account's owner.
+const sourceOwner = -1
+
+// transfer moves funds between two accounts with the same currency on behalf of
+// userID, within the user's transaction limit, annotating both history entries with
+// the memo. If expectedBalance is non-nil, the source balance must equal it. It
+// returns the ID of the debit transaction.
+func (b *BankService) transfer(userID, fromID, toID int, amount float64, memo string, expectedBalance *float64) (int64, error) {
+	defer b.observeDuration(OpTransfer, time.Now())
+
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+
+	fromAccount, err := b.getAccount(fromID)
+	if err != nil {
+		return 0, err
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return 0, err
+	}
+
+	currency := fromAccount.currency
+	if toAccount.currency != currency {
+		return 0, ErrCurrencyMismatch
+	}
+
+	// Transfers without an initiating user act on behalf of the source account's owner.
+	if userID == sourceOwner {
+		userID = fromAccount.ownerID
+	}
+	if err := b.checkActive(fromAccount.ownerID); err != nil {
+		return 0, err
+	}
+	if err := b.checkTransactionLimit(userID, OpTransfer, amount); err != nil {
+		return 0, err
+	}
+	if err := b.throttle(fromID); err != nil {
+		return 0, err
+	}
+
+	defer b.queueAccounts(fromID, fromAccount, toID, toAccount)()
+	unlock, err := lockAccounts(fromID, fromAccount, toID, toAccount, b.getLockTimeout())
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if expectedBalance != nil && fromAccount.balance != *expectedBalance {
+		return 0, accountError(fromID, ErrVersionConflict)
+	}
+	if fromAccount.frozen {
+		return 0, accountError(fromID, ErrAccountFrozen)
+	}
+	if toAccount.closed {
+		return 0, accountError(toID, ErrAccountClosed)
+	}
+
+	if fromAccount.available()+fromAccount.overdraftLimit < amount {
+		return 0, ErrInsufficientBalance
+	}
+	if err := toAccount.checkCredit(toID, amount); err != nil {
+		return 0, err
+	}
+
+	b.assertCurrency(fromID, fromAccount, currency)
+	b.assertCurrency(toID, toAccount, currency)
+
+	fromAccount.balance -= amount
+	toAccount.balance += amount
+	debit := b.record(fromID, fromAccount, Transaction{Type: TxTransferOut, Amount: amount, Counterparty: toID, Memo: memo})
+	b.record(toID, toAccount, Transaction{Type: TxTransferIn, Amount: amount, Counterparty: fromID, Memo: memo})
+	fmt.Printf("Transferred %.2f from account %d to account %d\n", amount, fromID, toID)
+	return debit.ID, nil
+}
+
+// TransferUpTo transfers as much as the source account can afford, up to amount,
+// and returns the amount actually transferred.
+func (b *BankService) TransferUpTo(userID, fromID, toID int, amount float64) (float64, error) {
+	defer b.observeDuration(OpTransfer, time.Now())
+
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return 0, err
+	}
+	if err := b.checkTransactionLimit(userID, OpTransfer, amount); err != nil {
+		return 0, err
+	}
+	if err := b.throttle(fromID); err != nil {
+		return 0, err
+	}
+
+	fromAccount, err := b.getAccount(fromID)
+	if err != nil {
+		return 0, err
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return 0, err
+	}
+
+	if fromAccount.currency != toAccount.currency {
+		return 0, ErrCurrencyMismatch
+	}
+
+	defer b.queueAccounts(fromID, fromAccount, toID, toAccount)()
+	unlock, err := lockAccounts(fromID, fromAccount, toID, toAccount, b.getLockTimeout())
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if fromAccount.frozen {
+		return 0, accountError(fromID, ErrAccountFrozen)
+	}
+	if toAccount.closed {
+		return 0, accountError(toID, ErrAccountClosed)
+	}
+
+	transferred := math.Min(amount, fromAccount.available())
+	if transferred <= 0 {
+		return 0, nil // Nothing left to move
+	}
+	if err := toAccount.checkCredit(toID, transferred); err != nil {
+		return 0, err
+	}
+
+	fromAccount.balance -= transferred
+	toAccount.balance += transferred
+	b.recordTransaction(fromID, fromAccount, TxTransferOut, transferred, toID)
+	b.recordTransaction(toID, toAccount, TxTransferIn, transferred, fromID)
+	fmt.Printf("Transferred %.2f of %.2f from account %d to account %d\n", transferred, amount, fromID, toID)
+	return transferred, nil
+}
+
+// SetExchangeRate sets the exchange rate between two currencies. The rate must be
+// positive and lie within any bounds configured for the pair.
+func (b *BankService) SetExchangeRate(from, to string, rate float64) error {
+	if !validRate(rate) {
+		return ErrInvalidRate
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := from + ":" + to
+	if bounds, exists := b.rateBounds[key]; exists && (rate < bounds[0] || rate > bounds[1]) {
+		return ErrRateOutOfBounds
+	}
+
+	b.exchangeRates[key] = rate
+	b.rateUpdated[key] = b.clock()
+	fmt.Printf("Set exchange rate %s -> %s: %.2f\n", from, to, rate)
+	return nil
+}
+
+// getExchangeRate retrieves the exchange rate between two currencies, consulting
+// the rate provider if none is set.
+func (b *BankService) getExchangeRate(from, to string) (float64, error) {
+	b.mutex.Lock()
+	rate, exists := b.exchangeRates[from+":"+to]
+	b.mutex.Unlock()
+
+	if !exists {
+		return b.providerRate(from, to)
+	}
+	return rate, nil
+}
+
+// ExchangeCurrency exchanges an amount from one currency to another. Like a
+// transfer, it may draw the source account into its overdraft.
+func (b *BankService) ExchangeCurrency(userID, fromID, toID int, amount float64) error {
+	defer b.observeDuration(OpExchange, time.Now())
+
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return err
+	}
+	if err := b.CheckPermissions(userID, toID); err != nil {
+		return err
+	}
+	return b.exchange(userID, fromID, toID, amount)
+}
+
+// TransferWithExchange transfers an amount to an account in another currency,
+// which may belong to a different user, converting at the configured rate.
+func (b *BankService) TransferWithExchange(userID, fromID, toID int, amount float64) error {
+	defer b.observeDuration(OpExchange, time.Now())
+
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return err
+	}
+	if _, err := b.getAccount(toID); err != nil {
+		return err
+	}
+	return b.exchange(userID, fromID, toID, amount)
+}
+
+// exchange debits amount from the source account and credits its converted value,
+// rounded to the destination currency's minor units, to the destination account.
+// The sub-unit residual of the conversion is not credited.
+func (b *BankService) exchange(userID, fromID, toID int, amount float64) error {
+	if err := b.checkTransactionLimit(userID, OpExchange, amount); err != nil {
+		return err
+	}
+
+	fromAccount := b.lookupAccount(fromID)
+	toAccount := b.lookupAccount(toID)
+	fromCurrency, toCurrency := fromAccount.currency, toAccount.currency
+
+	// Same-currency exchange needs no rate and behaves like a transfer.
+	if fromCurrency == toCurrency {
+		return b.Transfer(fromID, toID, amount)
+	}
+
+	// A rate keyed on an unregistered currency code must not apply.
+	if !b.isKnownCurrency(fromCurrency) || !b.isKnownCurrency(toCurrency) {
+		return ErrUnknownCurrency
+	}
+	if err := b.throttle(fromID); err != nil {
+		return err
+	}
+	rate, stale, err := b.getTradingRate(fromCurrency, toCurrency)
+	if err != nil {
+		return err
+	}
+	if stale {
+		b.emit(Event{Type: EventStaleExchangeRate, AccountID: fromID, UserID: userID, Amount: amount})
+	}
+	credit := b.roundToMinorUnits(amount*rate, toCurrency)
+
+	defer b.queueAccounts(fromID, fromAccount, toID, toAccount)()
+	unlock, err := lockAccounts(fromID, fromAccount, toID, toAccount, b.getLockTimeout())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if fromAccount.frozen {
+		return accountError(fromID, ErrAccountFrozen)
+	}
+	if toAccount.closed {
+		return accountError(toID, ErrAccountClosed)
+	}
+
+	if fromAccount.available()+fromAccount.overdraftLimit < amount {
+		return ErrInsufficientBalance
+	}
+	if err := toAccount.checkCredit(toID, credit); err != nil {
+		return err
+	}
+
+	b.assertCurrency(fromID, fromAccount, fromCurrency)
+	b.assertCurrency(toID, toAccount, toCurrency)
+
+	fromAccount.balance -= amount
+	toAccount.balance += credit
+	b.record(fromID, fromAccount, Transaction{Type: TxExchangeOut, Amount: amount, Counterparty: toID, Rate: rate})
+	b.record(toID, toAccount, Transaction{Type: TxExchangeIn, Amount: credit, Counterparty: fromID, Rate: rate})
+	fmt.Printf("Exchanged %.2f %s to %.2f %s\n", amount, fromAccount.currency, credit, toAccount.currency)
+	return nil
+}
+
+// lockAccounts locks two accounts in ascending account-ID order, so that concurrent
+// operations in opposite directions cannot deadlock. It returns a function that
+// releases both locks.
+func lockAccounts(idA int, a *Account, idB int, b *Account, timeout time.Duration) (func(), error) {
+	if idA == idB {
+		if err := lockWithTimeout(&a.mutex, timeout); err != nil {
+			return nil, err
+		}
+		return a.mutex.Unlock, nil
+	}
+	if idA > idB {
+		a, b = b, a
+	}
+
+	start := time.Now()
+	if err := lockWithTimeout(&a.mutex, timeout); err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		// Share the timeout across both acquisitions.
+		timeout -= time.Since(start)
+		if timeout <= 0 {
+			a.mutex.Unlock()
+			return nil, ErrLockTimeout
+		}
+	}
+	if err := lockWithTimeout(&b.mutex, timeout); err != nil {
+		a.mutex.Unlock()
+		return nil, err
+	}
+	return func() {
+		b.mutex.Unlock()
+		a.mutex.Unlock()
+	}, nil
+}
+
+// lockAll locks distinct accounts in ascending account-ID order, like lockAccounts,
+// sharing the timeout across every acquisition. It returns a function that releases
+// every lock, or ErrLockTimeout after releasing the locks it took.
+func lockAll(ids []int, accounts []*Account, timeout time.Duration) (func(), error) {
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(x, y int) bool {
+		return ids[order[x]] < ids[order[y]]
+	})
+
+	unlock := func(locked []int) {
+		for _, i := range locked {
+			accounts[i].mutex.Unlock()
+		}
+	}
+	deadline := time.Now().Add(timeout)
+	for n, i := range order {
+		remaining := timeout
+		if timeout > 0 {
+			if remaining = time.Until(deadline); remaining <= 0 {
+				unlock(order[:n])
+				return nil, ErrLockTimeout
+			}
+		}
+		if err := lockWithTimeout(&accounts[i].mutex, remaining); err != nil {
+			unlock(order[:n])
+			return nil, err
+		}
+	}
+	return func() { unlock(order) }, nil
+}
+
+// lockPollInterval is how often lockWithTimeout retries a contended lock.
+const lockPollInterval = 100 * time.Microsecond
+
+// lockWithTimeout acquires the write lock, giving up with ErrLockTimeout once the
+// timeout elapses. A non-positive timeout waits indefinitely.
+func lockWithTimeout(m *sync.RWMutex, timeout time.Duration) error {
+	return acquireWithTimeout(m.Lock, m.TryLock, timeout)
+}
+
+// rlockWithTimeout acquires the read lock like lockWithTimeout.
+func rlockWithTimeout(m *sync.RWMutex, timeout time.Duration) error {
+	return acquireWithTimeout(m.RLock, m.TryRLock, timeout)
+}
+
+// acquireWithTimeout takes a lock through lock, or by polling tryLock until the
+// timeout elapses if it is positive.
+func acquireWithTimeout(lock func(), tryLock func() bool, timeout time.Duration) error {
+	if timeout <= 0 {
+		lock()
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !tryLock() {
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(lockPollInterval)
+	}
+	return nil
+}
+
+// SetLockTimeout sets how long operations wait for an account lock before failing
+// with ErrLockTimeout. A zero duration waits indefinitely.
+func (b *BankService) SetLockTimeout(d time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lockTimeout = d
+}
+
+// getLockTimeout retrieves the configured account lock timeout.
+func (b *BankService) getLockTimeout() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.lockTimeout
+}
+
+// getAccount retrieves an account by its ID.
+func (b *BankService) getAccount(accountID int) (*Account, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	account, exists := b.accounts[accountID]
+	if !exists {
+		return nil, accountError(accountID, ErrAccountNotExist)
+	}
+	return account, nil
+}
+
+// lookupAccount retrieves an account whose existence the caller has already
+// verified, e.g. through CheckPermissions. Accounts are never removed.
+func (b *BankService) lookupAccount(accountID int) *Account {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.accounts[accountID]
 }
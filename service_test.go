@@ -2,8 +2,11 @@ package main
 
 import (
 	"errors"
+	"math"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 )
 
 // TestCreateUser ensures that users are created with correct roles and settings.
@@ -68,6 +71,26 @@ func TestDeposit(t *testing.T) {
 	}
 }
 
+// TestDepositInCurrency ensures deposits in a currency other than the account's are
+// rejected without crediting it.
+func TestDepositInCurrency(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	if err := bank.DepositInCurrency(1, accID, 200, EUR); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	if err := bank.DepositInCurrency(1, accID, 200, USD); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 700 {
+		t.Errorf("expected balance 700, got %.2f", balance)
+	}
+}
+
 // TestWithdraw ensures that withdrawals reduce the account balance.
 func TestWithdraw(t *testing.T) {
 	bank := NewBankService()
@@ -180,6 +203,25 @@ func TestExchangeRateNotFound(t *testing.T) {
 	}
 }
 
+// TestExchangeUnregisteredCurrency ensures a rate keyed on an unregistered currency
+// isn't used.
+func TestExchangeUnregisteredCurrency(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, ExchangeManager, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, "XYZ")
+	bank.SetExchangeRate(USD, "XYZ", 2)
+
+	err := bank.ExchangeCurrency(1, acc1, acc2, 100)
+	if !errors.Is(err, ErrUnknownCurrency) {
+		t.Fatalf("expected ErrUnknownCurrency, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, acc1); balance != 1000 {
+		t.Errorf("expected balance 1000, got %.2f", balance)
+	}
+}
+
 // TestUnauthorizedAccess ensures unauthorized users can't access accounts.
 func TestUnauthorizedAccess(t *testing.T) {
 	bank := NewBankService()
@@ -298,3 +340,1216 @@ func TestConcurrentTransfers(t *testing.T) {
 			expectedBalance1, expectedBalance2, balance1, balance2)
 	}
 }
+
+// TestChangeRole ensures a Banker can change another user's role.
+func TestChangeRole(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Banker, false)
+	bank.CreateUser(2, Teller, false)
+
+	err := bank.ChangeRole(1, 2, ExchangeManager)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if role := bank.users[2].Role; role != ExchangeManager {
+		t.Errorf("expected user role to be %s, got %s", ExchangeManager, role)
+	}
+}
+
+// TestChangeRoleUnauthorized ensures only a Banker can change roles.
+func TestChangeRoleUnauthorized(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Teller, false)
+
+	err := bank.ChangeRole(1, 2, ExchangeManager)
+	if !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+
+	if role := bank.users[2].Role; role != Teller {
+		t.Errorf("expected user role to remain %s, got %s", Teller, role)
+	}
+}
+
+// TestChangeRoleInvalid ensures unknown roles are rejected.
+func TestChangeRoleInvalid(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Banker, false)
+	bank.CreateUser(2, Teller, false)
+
+	err := bank.ChangeRole(1, 2, Role("manager"))
+	if !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+}
+
+// TestCreateUserInvalidRole ensures users can't be created with a mistyped role.
+func TestCreateUserInvalidRole(t *testing.T) {
+	bank := NewBankService()
+
+	if err := bank.CreateUser(1, Role("custmer"), false); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+	if _, exists := bank.users[1]; exists {
+		t.Error("expected no user to be created")
+	}
+	if err := bank.CreateUser(1, Customer, false); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+// TestValidRole ensures only the defined roles are valid.
+func TestValidRole(t *testing.T) {
+	for _, role := range []Role{Customer, Banker, Teller, ExchangeManager} {
+		if !ValidRole(role) {
+			t.Errorf("expected %s to be valid", role)
+		}
+	}
+	if ValidRole("custmer") {
+		t.Error("expected custmer to be invalid")
+	}
+}
+
+// TestConcurrentOpposingTransfers ensures transfers in opposite directions don't deadlock.
+func TestConcurrentOpposingTransfers(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 1000, USD)
+
+	var wg sync.WaitGroup
+	numTransfers := 1000
+
+	for i := 0; i < numTransfers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = bank.Transfer(acc1, acc2, 1)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = bank.Transfer(acc2, acc1, 1)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("transfers did not complete, possible deadlock")
+	}
+
+	balance1, _, _ := bank.GetBalance(1, acc1)
+	balance2, _, _ := bank.GetBalance(1, acc2)
+	if balance1+balance2 != 2000 {
+		t.Errorf("expected total balance 2000, got %.2f", balance1+balance2)
+	}
+}
+
+// TestTransferUpTo ensures a partial transfer moves only what the source can afford.
+func TestTransferUpTo(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 300, USD)
+	acc2, _ := bank.CreateAccount(1, 100, USD)
+
+	transferred, err := bank.TransferUpTo(1, acc1, acc2, 500)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if transferred != 300 {
+		t.Errorf("expected 300 transferred, got %.2f", transferred)
+	}
+
+	balance1, _, _ := bank.GetBalance(1, acc1)
+	balance2, _, _ := bank.GetBalance(1, acc2)
+	if balance1 != 0 || balance2 != 400 {
+		t.Errorf("expected balances to be 0 and 400, got %.2f and %.2f", balance1, balance2)
+	}
+
+	// Nothing left to move.
+	transferred, err = bank.TransferUpTo(1, acc1, acc2, 500)
+	if err != nil || transferred != 0 {
+		t.Errorf("expected 0 transferred and no error, got %.2f and %v", transferred, err)
+	}
+}
+
+// TestGetBalanceIn ensures balances are converted to the requested currency.
+func TestGetBalanceIn(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 100, EUR)
+	bank.SetExchangeRate(EUR, USD, 1.1)
+
+	balance, err := bank.GetBalanceIn(1, accID, USD)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if math.Abs(balance-110) > 1e-9 {
+		t.Errorf("expected balance 110, got %.2f", balance)
+	}
+
+	_, err = bank.GetBalanceIn(1, accID, GBP)
+	if !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Fatalf("expected ErrExchangeRateNotFound, got %v", err)
+	}
+}
+
+// TestConcurrentCreateAccountAndBackupWithdrawals ensures creating accounts doesn't
+// race with withdrawals that scan the user's accounts for backup funds. Run with -race.
+func TestConcurrentCreateAccountAndBackupWithdrawals(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+	primary, _ := bank.CreateAccount(1, 0, USD)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			bank.CreateAccount(1, 10, USD)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			bank.Withdraw(1, primary, 5)
+		}
+	}()
+	wg.Wait()
+
+	total := 0.0
+	for _, accID := range bank.users[1].Accounts {
+		balance, _, _ := bank.GetBalance(1, accID)
+		total += balance
+
+		history, _ := bank.GetTransactionHistory(1, accID)
+		for _, tx := range history {
+			if tx.Type == TxWithdrawal {
+				total += tx.Amount
+			}
+		}
+	}
+	if total != 1000 {
+		t.Errorf("expected balances and withdrawals to add up to 1000, got %.2f", total)
+	}
+}
+
+// TestConcurrentCreateUserAndWithdrawals ensures creating users doesn't race with
+// withdrawals reading the withdrawing user's settings. Run with -race.
+func TestConcurrentCreateUserAndWithdrawals(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			bank.CreateUser(100+i, Customer, false)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			bank.Withdraw(1, accID, 5)
+		}
+	}()
+	wg.Wait()
+
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 500 {
+		t.Errorf("expected balance 500, got %.2f", balance)
+	}
+}
+
+// TestConcurrentBackupWithdrawalsAndIntegrityCheck ensures a withdrawal drawing on
+// backup funds doesn't deadlock with CheckIntegrity, which locks accounts under the
+// bank lock. The archive hook runs while the withdrawal holds the primary account's
+// lock, so it starts the integrity check right then to force the interleaving.
+func TestConcurrentBackupWithdrawalsAndIntegrityCheck(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+	primary, _ := bank.CreateAccount(1, 10, USD)
+	bank.CreateAccount(1, 100, USD)
+
+	var once sync.Once
+	bank.SetHistoryLimit(1)
+	bank.OnArchive(func(tx Transaction) {
+		if tx.AccountID != primary {
+			return
+		}
+		once.Do(func() {
+			go bank.CheckIntegrity()
+			time.Sleep(20 * time.Millisecond) // Let it take the bank lock
+		})
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- bank.Withdraw(1, primary, 15)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock between backup withdrawal and CheckIntegrity")
+	}
+}
+
+// TestGetBalanceConcurrentReads ensures lock-free reads only ever observe committed balances.
+func TestGetBalanceConcurrentReads(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			bank.Deposit(1, accID, 10)
+		}
+	}()
+
+	last := 0.0
+	for i := 0; i < 1000; i++ {
+		balance, _, err := bank.GetBalance(1, accID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if balance < last || math.Mod(balance, 10) != 0 {
+			t.Fatalf("read uncommitted balance %.2f after %.2f", balance, last)
+		}
+		last = balance
+	}
+	wg.Wait()
+
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 10000 {
+		t.Errorf("expected balance 10000, got %.2f", balance)
+	}
+}
+
+// TestBalanceCache ensures cached reads reflect every committed write while other
+// readers run, and reads stay correct with the cache disabled.
+func TestBalanceCache(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					bank.GetBalance(1, accID)
+				}
+			}
+		}()
+	}
+
+	for i := 1; i <= 200; i++ {
+		bank.Deposit(1, accID, 1)
+		if balance, _, _ := bank.GetBalance(1, accID); balance != float64(i) {
+			t.Fatalf("expected balance %d after write, got %.2f", i, balance)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	bank.SetBalanceCacheEnabled(false)
+	bank.Withdraw(1, accID, 50)
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 150 {
+		t.Errorf("expected balance 150, got %.2f", balance)
+	}
+}
+
+// BenchmarkGetBalanceUnderWrites measures balance reads while another goroutine deposits.
+func BenchmarkGetBalanceUnderWrites(b *testing.B) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				bank.Deposit(1, accID, 1)
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			bank.GetBalance(1, accID)
+		}
+	})
+}
+
+// TestLockTimeout ensures operations fail instead of blocking on a held account lock.
+func TestLockTimeout(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	bank.SetLockTimeout(20 * time.Millisecond)
+
+	account := bank.accounts[accID]
+	account.mutex.Lock()
+	err := bank.Withdraw(1, accID, 100)
+	account.mutex.Unlock()
+
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 500 {
+		t.Errorf("expected balance 500, got %.2f", balance)
+	}
+}
+
+// TestLockTimeoutMultipleAccounts ensures operations locking several accounts at
+// once respect the lock timeout and release the locks they took.
+func TestLockTimeoutMultipleAccounts(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+	primary, _ := bank.CreateAccount(1, 10, USD)
+	backup, _ := bank.CreateAccount(1, 100, USD)
+
+	bank.SetLockTimeout(10 * time.Millisecond)
+
+	// A reader lets the backups be ranked but keeps them from being locked.
+	account := bank.accounts[backup]
+	account.mutex.RLock()
+	errs := map[string]error{
+		"Withdraw":     bank.Withdraw(1, primary, 50),
+		"TransferVia":  bank.TransferVia(1, []int{primary, backup}, 5),
+		"DepositSplit": bank.DepositSplit(1, []Allocation{{primary, 0.5}, {backup, 0.5}}, 10),
+	}
+	account.mutex.RUnlock()
+
+	for name, err := range errs {
+		if !errors.Is(err, ErrLockTimeout) {
+			t.Errorf("%s: expected ErrLockTimeout, got %v", name, err)
+		}
+	}
+	if !bank.accounts[primary].mutex.TryLock() {
+		t.Fatal("expected the primary account to be unlocked")
+	}
+	bank.accounts[primary].mutex.Unlock()
+}
+
+// TestTransferWithRetry ensures transfers retry past a temporarily held lock but
+// not past other errors.
+func TestTransferWithRetry(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	acc1, _ := bank.CreateAccount(1, 500, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	bank.SetLockTimeout(10 * time.Millisecond)
+
+	account := bank.accounts[acc2]
+	account.mutex.Lock()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		account.mutex.Unlock()
+	}()
+
+	if err := bank.TransferWithRetry(1, acc1, acc2, 100, 10, 10*time.Millisecond); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 100 {
+		t.Errorf("expected balance 100, got %.2f", balance)
+	}
+
+	start := time.Now()
+	err := bank.TransferWithRetry(1, acc1, acc2, 1000, 10, time.Second)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance error, got %v", err)
+	}
+	if time.Since(start) >= time.Second {
+		t.Errorf("expected no retry on insufficient balance")
+	}
+}
+
+// TestExchangeSameCurrency ensures same-currency exchange behaves like a transfer.
+func TestExchangeSameCurrency(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	err := bank.ExchangeCurrency(1, acc1, acc2, 100)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance1, _, _ := bank.GetBalance(1, acc1)
+	balance2, _, _ := bank.GetBalance(1, acc2)
+	if balance1 != 900 || balance2 != 100 {
+		t.Errorf("expected balances to be 900 and 100, got %.2f and %.2f", balance1, balance2)
+	}
+}
+
+// TestRoleTransactionLimit ensures a Teller can't exceed the per-transaction cap.
+func TestRoleTransactionLimit(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Teller, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	bank.SetRoleTransactionLimit(Teller, OpDeposit, 1000)
+
+	if err := bank.Deposit(1, accID, 1000); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err := bank.Deposit(1, accID, 1500)
+	if !errors.Is(err, ErrTransactionLimitExceeded) {
+		t.Fatalf("expected ErrTransactionLimitExceeded, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 1000 {
+		t.Errorf("expected balance 1000, got %.2f", balance)
+	}
+}
+
+// TestRoleTransactionLimitTransfers ensures every way of transferring enforces the
+// role's transfer cap.
+func TestRoleTransactionLimitTransfers(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Teller, false)
+	acc1, _ := bank.CreateAccount(1, 5000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	bank.SetRoleTransactionLimit(Teller, OpTransfer, 100)
+
+	transfers := map[string]func() error{
+		"Transfer": func() error { return bank.Transfer(acc1, acc2, 1000) },
+		"TransferWithMemo": func() error {
+			_, err := bank.TransferWithMemo(1, acc1, acc2, 1000, "rent")
+			return err
+		},
+		"TransferIfBalance": func() error { return bank.TransferIfBalance(1, acc1, acc2, 1000, 5000) },
+		"TransferWithRetry": func() error { return bank.TransferWithRetry(1, acc1, acc2, 1000, 3, time.Millisecond) },
+		"TransferUpTo": func() error {
+			_, err := bank.TransferUpTo(1, acc1, acc2, 1000)
+			return err
+		},
+		"TransferVia": func() error { return bank.TransferVia(1, []int{acc1, acc2}, 1000) },
+		"RequestTransfer": func() error {
+			_, err := bank.RequestTransfer(1, acc1, acc2, 1000)
+			return err
+		},
+	}
+	for name, transfer := range transfers {
+		if err := transfer(); !errors.Is(err, ErrTransactionLimitExceeded) {
+			t.Errorf("%s: expected ErrTransactionLimitExceeded, got %v", name, err)
+		}
+	}
+
+	if balance, _, _ := bank.GetBalance(1, acc2); balance != 0 {
+		t.Errorf("expected balance 0, got %.2f", balance)
+	}
+}
+
+// TestRoleTransactionLimitBanker ensures a Banker isn't bound by another role's cap.
+func TestRoleTransactionLimitBanker(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	bank.SetRoleTransactionLimit(Teller, OpDeposit, 1000)
+
+	if err := bank.Deposit(2, accID, 5000); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	if balance != 5000 {
+		t.Errorf("expected balance 5000, got %.2f", balance)
+	}
+}
+
+// TestBankErrorContext ensures errors carry the offending account and user IDs.
+func TestBankErrorContext(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	_, _, err := bank.GetBalance(1, 42)
+	var bankErr *BankError
+	if !errors.As(err, &bankErr) {
+		t.Fatalf("expected a BankError, got %v", err)
+	}
+	if bankErr.AccountID != 42 || !errors.Is(err, ErrAccountNotExist) {
+		t.Errorf("expected ErrAccountNotExist for account 42, got %v", err)
+	}
+
+	err = bank.Withdraw(2, accID, 100)
+	if !errors.As(err, &bankErr) {
+		t.Fatalf("expected a BankError, got %v", err)
+	}
+	if bankErr.UserID != 2 || bankErr.AccountID != accID || !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected ErrUnauthorizedAccess for user 2 on account %d, got %v", accID, err)
+	}
+}
+
+// TestWithdrawDetailed ensures the withdrawal breakdown lists the primary and backup accounts used.
+func TestWithdrawDetailed(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD) // Primary account
+	acc2, _ := bank.CreateAccount(1, 50, USD)  // Backup account 1
+	acc3, _ := bank.CreateAccount(1, 100, USD) // Backup account 2
+
+	result, err := bank.WithdrawDetailed(1, acc1, 200)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []WithdrawalPart{
+		{AccountID: acc1, Amount: 100},
+		{AccountID: acc2, Amount: 50},
+		{AccountID: acc3, Amount: 50},
+	}
+	if len(result.Parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %d", len(expected), len(result.Parts))
+	}
+	for i, part := range result.Parts {
+		if part != expected[i] {
+			t.Errorf("expected part %d to be %+v, got %+v", i, expected[i], part)
+		}
+	}
+	if !result.UsedBackupFunds() || result.Total() != 200 {
+		t.Errorf("expected backup funds used and total 200, got %t and %.2f", result.UsedBackupFunds(), result.Total())
+	}
+}
+
+// TestPartialWithdrawal ensures uncovered withdrawals fail without debiting any
+// account by default and withdraw what's available when the user allows partial
+// withdrawals.
+func TestPartialWithdrawal(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+
+	bank.CreateAccount(1, 100, USD)
+	bank.CreateAccount(1, 50, USD)
+
+	if err := bank.CanWithdraw(1, 0, 200); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected CanWithdraw to report insufficient balance, got %v", err)
+	}
+	result, err := bank.WithdrawDetailed(1, 0, 200)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance error, got %v", err)
+	}
+	if len(result.Parts) != 0 {
+		t.Errorf("expected no parts, got %v", result.Parts)
+	}
+	primaryBalance, _, _ := bank.GetBalance(1, 0)
+	backupBalance, _, _ := bank.GetBalance(1, 1)
+	if primaryBalance != 100 || backupBalance != 50 {
+		t.Errorf("expected balances 100 and 50 to be unchanged, got %.2f and %.2f", primaryBalance, backupBalance)
+	}
+
+	if err := bank.SetPartialWithdrawalAllowed(1, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := bank.CanWithdraw(1, 0, 200); err != nil {
+		t.Errorf("expected CanWithdraw to allow a partial withdrawal, got %v", err)
+	}
+	result, err = bank.WithdrawDetailed(1, 0, 200)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Total() != 150 {
+		t.Errorf("expected 150 withdrawn, got %.2f", result.Total())
+	}
+
+	// With every account empty there is nothing to withdraw, even partially.
+	if err := bank.CanWithdraw(1, 0, 50); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected CanWithdraw to report insufficient balance, got %v", err)
+	}
+	if _, err := bank.WithdrawDetailed(1, 0, 50); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected insufficient balance error from empty accounts, got %v", err)
+	}
+}
+
+// TestWithdrawIfVersion ensures conditional withdrawals fail once another mutation
+// has changed the account.
+func TestWithdrawIfVersion(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	info, _ := bank.GetAccountInfo(1, accID)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bank.Deposit(1, accID, 10)
+	}()
+	wg.Wait()
+
+	if err := bank.WithdrawIfVersion(1, accID, 50, info.Version); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 110 {
+		t.Errorf("expected balance 110, got %.2f", balance)
+	}
+
+	info, _ = bank.GetAccountInfo(1, accID)
+	if err := bank.WithdrawIfVersion(1, accID, 50, info.Version); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+// TestTransferIfBalance ensures a transfer planned against a balance that has since
+// changed is rejected, and goes through once the plan is refreshed.
+func TestTransferIfBalance(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	fromID, _ := bank.CreateAccount(1, 100, USD)
+	toID, _ := bank.CreateAccount(1, 0, USD)
+
+	planned, _, _ := bank.GetBalance(1, fromID)
+	bank.Withdraw(1, fromID, 30)
+
+	if err := bank.TransferIfBalance(1, fromID, toID, 50, planned); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, toID); balance != 0 {
+		t.Errorf("expected destination balance 0, got %.2f", balance)
+	}
+
+	planned, _, _ = bank.GetBalance(1, fromID)
+	if err := bank.TransferIfBalance(1, fromID, toID, 50, planned); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, fromID); balance != 20 {
+		t.Errorf("expected source balance 20, got %.2f", balance)
+	}
+}
+
+// TestTransferMaxBalance ensures a transfer that would push the destination past its
+// cap fails without touching either account.
+func TestTransferMaxBalance(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 500, USD)
+	acc2, _ := bank.CreateAccount(1, 90, USD)
+	bank.SetMaxBalance(acc2, 100)
+
+	if err := bank.Transfer(acc1, acc2, 20); !errors.Is(err, ErrMaxBalanceExceeded) {
+		t.Fatalf("expected ErrMaxBalanceExceeded, got %v", err)
+	}
+
+	balance1, _, _ := bank.GetBalance(1, acc1)
+	balance2, _, _ := bank.GetBalance(1, acc2)
+	if balance1 != 500 || balance2 != 90 {
+		t.Errorf("expected balances 500 and 90, got %.2f and %.2f", balance1, balance2)
+	}
+
+	if err := bank.Transfer(acc1, acc2, 10); err != nil {
+		t.Errorf("expected transfer up to the cap to succeed, got %v", err)
+	}
+}
+
+// TestBalanceOverflow ensures credits that would overflow a balance are rejected.
+func TestBalanceOverflow(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, math.MaxFloat64, USD)
+	acc2, _ := bank.CreateAccount(1, math.MaxFloat64, USD)
+	eurID, _ := bank.CreateAccount(1, math.MaxFloat64, EUR)
+	bank.SetExchangeRate(USD, EUR, 2)
+
+	if err := bank.Deposit(1, acc1, math.MaxFloat64); !errors.Is(err, ErrBalanceOverflow) {
+		t.Errorf("expected deposit overflow error, got %v", err)
+	}
+	if err := bank.Transfer(acc2, acc1, math.MaxFloat64); !errors.Is(err, ErrBalanceOverflow) {
+		t.Errorf("expected transfer overflow error, got %v", err)
+	}
+	if err := bank.ExchangeCurrency(1, acc2, eurID, math.MaxFloat64); !errors.Is(err, ErrBalanceOverflow) {
+		t.Errorf("expected exchange overflow error, got %v", err)
+	}
+
+	for _, accID := range []int{acc1, acc2, eurID} {
+		if balance, _, _ := bank.GetBalance(1, accID); balance != math.MaxFloat64 {
+			t.Errorf("expected account %d balance to be unchanged, got %g", accID, balance)
+		}
+	}
+}
+
+// TestTransferOwnership ensures an account moves to its new owner along with access.
+func TestTransferOwnership(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	if err := bank.TransferOwnership(2, accID, 2); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+	if err := bank.TransferOwnership(1, accID, 3); !errors.Is(err, ErrUserNotExist) {
+		t.Fatalf("expected ErrUserNotExist, got %v", err)
+	}
+
+	if err := bank.TransferOwnership(1, accID, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := bank.Withdraw(1, accID, 10); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected old owner to lose access, got %v", err)
+	}
+	if err := bank.Withdraw(2, accID, 10); err != nil {
+		t.Errorf("expected new owner to gain access, got %v", err)
+	}
+	if len(bank.users[1].Accounts) != 0 || !slices.Equal(bank.users[2].Accounts, []int{accID}) {
+		t.Errorf("expected account to move from user 1 to user 2, got %v and %v", bank.users[1].Accounts, bank.users[2].Accounts)
+	}
+}
+
+// TestWithdrawWithOnlyOtherCurrencyBackups ensures backups in other currencies are
+// never tapped and the primary is left untouched when nothing can cover the amount.
+func TestWithdrawWithOnlyOtherCurrencyBackups(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+
+	usdID, _ := bank.CreateAccount(1, 100, USD)
+	eurID, _ := bank.CreateAccount(1, 500, EUR)
+
+	if err := bank.Withdraw(1, usdID, 200); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance error, got %v", err)
+	}
+
+	usdBalance, _, _ := bank.GetBalance(1, usdID)
+	eurBalance, _, _ := bank.GetBalance(1, eurID)
+	if usdBalance != 100 || eurBalance != 500 {
+		t.Errorf("expected balances 100 and 500, got %.2f and %.2f", usdBalance, eurBalance)
+	}
+}
+
+// TestTellerViewAccess ensures Tellers can view but not withdraw from other users' accounts.
+func TestTellerViewAccess(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Teller, false)
+
+	accID, _ := bank.CreateAccount(1, 500, USD)
+
+	balance, currency, err := bank.GetBalance(2, accID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance != 500 || currency != USD {
+		t.Errorf("expected balance 500 and currency USD, got %.2f and %s", balance, currency)
+	}
+
+	err = bank.Withdraw(2, accID, 100)
+	if !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+}
+
+// TestCreateAccounts ensures a batch of accounts is created in order.
+func TestCreateAccounts(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	accountIDs, err := bank.CreateAccounts(1, []AccountSpec{
+		{InitialDeposit: 100, Currency: USD},
+		{InitialDeposit: 0, Currency: EUR},
+		{InitialDeposit: 50, Currency: GBP},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(accountIDs) != 3 {
+		t.Fatalf("expected 3 accounts, got %d", len(accountIDs))
+	}
+
+	balance, currency, _ := bank.GetBalance(1, accountIDs[2])
+	if balance != 50 || currency != GBP {
+		t.Errorf("expected balance 50 and currency GBP, got %.2f and %s", balance, currency)
+	}
+	if len(bank.users[1].Accounts) != 3 {
+		t.Errorf("expected user to own 3 accounts, got %d", len(bank.users[1].Accounts))
+	}
+}
+
+// TestCreateAccountsInvalidSpec ensures an invalid spec fails the whole batch.
+func TestCreateAccountsInvalidSpec(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	_, err := bank.CreateAccounts(1, []AccountSpec{
+		{InitialDeposit: 100, Currency: USD},
+		{InitialDeposit: 100, Currency: "XYZ"},
+	})
+	if !errors.Is(err, ErrUnknownCurrency) {
+		t.Fatalf("expected ErrUnknownCurrency, got %v", err)
+	}
+
+	_, err = bank.CreateAccounts(1, []AccountSpec{
+		{InitialDeposit: 100, Currency: USD},
+		{InitialDeposit: -1, Currency: USD},
+	})
+	if !errors.Is(err, ErrNegativeDeposit) {
+		t.Fatalf("expected ErrNegativeDeposit, got %v", err)
+	}
+
+	if len(bank.accounts) != 0 || len(bank.users[1].Accounts) != 0 {
+		t.Errorf("expected no accounts to be created")
+	}
+}
+
+// TestReserveAccountID ensures a reserved ID can be used to create an account.
+func TestReserveAccountID(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	reserved := bank.ReserveAccountID()
+
+	// Regular account creation must not reuse the reserved ID.
+	otherID, _ := bank.CreateAccount(1, 0, USD)
+	if otherID == reserved {
+		t.Fatalf("expected a different ID than the reserved %d", reserved)
+	}
+
+	if err := bank.CreateAccountWithID(reserved, 1, 100, USD); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, reserved)
+	if balance != 100 {
+		t.Errorf("expected balance 100, got %.2f", balance)
+	}
+}
+
+// TestCreateAccountWithIDCollision ensures an ID in use can't be reused.
+func TestCreateAccountWithIDCollision(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 100, USD)
+
+	err := bank.CreateAccountWithID(accID, 1, 0, USD)
+	if !errors.Is(err, ErrAccountIDInUse) {
+		t.Fatalf("expected ErrAccountIDInUse, got %v", err)
+	}
+}
+
+// TestDeactivatedUser ensures a deactivated user can't move funds out while deposits still succeed.
+func TestDeactivatedUser(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+	bank.CreateUser(2, Banker, false)
+
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+	acc3, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetExchangeRate(USD, EUR, 0.85)
+
+	if err := bank.DeactivateUser(2, 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := bank.Transfer(acc1, acc2, 100); !errors.Is(err, ErrUserInactive) {
+		t.Errorf("expected ErrUserInactive for transfer, got %v", err)
+	}
+	if err := bank.ExchangeCurrency(1, acc1, acc3, 100); !errors.Is(err, ErrUserInactive) {
+		t.Errorf("expected ErrUserInactive for exchange, got %v", err)
+	}
+	if err := bank.Withdraw(1, acc2, 100); !errors.Is(err, ErrUserInactive) {
+		t.Errorf("expected ErrUserInactive for backup withdrawal, got %v", err)
+	}
+
+	if err := bank.Deposit(2, acc1, 100); err != nil {
+		t.Errorf("expected deposit to succeed, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(2, acc1)
+	if balance != 1100 {
+		t.Errorf("expected balance 1100, got %.2f", balance)
+	}
+}
+
+// TestMaxAccountsPerUser ensures users can't exceed the account limit while Bankers are exempt.
+func TestMaxAccountsPerUser(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+	bank.SetMaxAccountsPerUser(2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := bank.CreateAccount(1, 0, USD); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	_, err := bank.CreateAccount(1, 0, USD)
+	if !errors.Is(err, ErrAccountLimitReached) {
+		t.Fatalf("expected ErrAccountLimitReached, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := bank.CreateAccount(2, 0, USD); err != nil {
+			t.Fatalf("expected Banker to be exempt, got %v", err)
+		}
+	}
+}
+
+// TestBackupWithdrawalDeterministic ensures the same scenario always drains the same backup accounts.
+func TestBackupWithdrawalDeterministic(t *testing.T) {
+	var expected []WithdrawalPart
+	for run := 0; run < 20; run++ {
+		bank := NewBankService()
+		bank.CreateUser(1, Customer, true)
+
+		primary, _ := bank.CreateAccount(1, 50, USD)
+		for _, deposit := range []float64{80, 30, 60, 40} {
+			bank.CreateAccount(1, deposit, USD)
+		}
+
+		result, err := bank.WithdrawDetailed(1, primary, 150)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if run == 0 {
+			expected = result.Parts
+			continue
+		}
+		if !slices.Equal(result.Parts, expected) {
+			t.Fatalf("run %d: expected parts %+v, got %+v", run, expected, result.Parts)
+		}
+	}
+}
+
+// TestBackupOrderByBalance ensures backups can be drained smallest balance first.
+func TestBackupOrderByBalance(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+	bank.SetBackupOrder(BackupByBalanceAsc)
+
+	primary, _ := bank.CreateAccount(1, 0, USD)
+	acc1, _ := bank.CreateAccount(1, 80, USD)
+	acc2, _ := bank.CreateAccount(1, 30, USD)
+
+	result, err := bank.WithdrawDetailed(1, primary, 50)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []WithdrawalPart{{AccountID: acc2, Amount: 30}, {AccountID: acc1, Amount: 20}}
+	if !slices.Equal(result.Parts, expected) {
+		t.Errorf("expected parts %+v, got %+v", expected, result.Parts)
+	}
+}
+
+// TestBackupPriority ensures higher-priority backups are drained first and accounts
+// without a priority come last.
+func TestBackupPriority(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+
+	primary, _ := bank.CreateAccount(1, 0, USD)
+	acc1, _ := bank.CreateAccount(1, 20, USD)
+	acc2, _ := bank.CreateAccount(1, 20, USD)
+	acc3, _ := bank.CreateAccount(1, 20, USD)
+	acc4, _ := bank.CreateAccount(1, 20, USD)
+
+	bank.SetBackupPriority(1, acc1, 1)
+	bank.SetBackupPriority(1, acc2, 5)
+	bank.SetBackupPriority(1, acc3, 3)
+
+	result, err := bank.WithdrawDetailed(1, primary, 70)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []WithdrawalPart{
+		{AccountID: acc2, Amount: 20},
+		{AccountID: acc3, Amount: 20},
+		{AccountID: acc1, Amount: 20},
+		{AccountID: acc4, Amount: 10},
+	}
+	if !slices.Equal(result.Parts, expected) {
+		t.Errorf("expected parts %+v, got %+v", expected, result.Parts)
+	}
+}
+
+// TestMaxBackupAccounts ensures withdrawals stop after tapping the allowed number of backups.
+func TestMaxBackupAccounts(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+	bank.SetMaxBackupAccounts(1, 1)
+
+	primary, _ := bank.CreateAccount(1, 0, USD)
+	acc1, _ := bank.CreateAccount(1, 50, USD)
+	bank.CreateAccount(1, 50, USD)
+	bank.CreateAccount(1, 50, USD)
+
+	if err := bank.Withdraw(1, primary, 50); err != nil {
+		t.Fatalf("expected a single backup to cover 50, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, acc1)
+	if balance != 0 {
+		t.Errorf("expected first backup to be drained, got %.2f", balance)
+	}
+
+	err := bank.Withdraw(1, primary, 100)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+}
+
+// TestTransferWithExchangeRounding ensures converted credits are rounded to the destination's minor units.
+func TestTransferWithExchangeRounding(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(2, 0, EUR)
+	bank.SetExchangeRate(USD, EUR, 0.8333)
+
+	if err := bank.TransferWithExchange(1, acc1, acc2, 10.01); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	balance1, _, _ := bank.GetBalance(1, acc1)
+	balance2, _, _ := bank.GetBalance(2, acc2)
+	if math.Abs(balance1-89.99) > 1e-9 {
+		t.Errorf("expected source balance 89.99, got %v", balance1)
+	}
+	if balance2 != 8.34 {
+		t.Errorf("expected credited amount 8.34, got %v", balance2)
+	}
+
+	history, _ := bank.GetTransactionHistory(2, acc2)
+	if credit := history[len(history)-1]; credit.Amount != 8.34 {
+		t.Errorf("expected recorded credit 8.34, got %v", credit.Amount)
+	}
+}
+
+// TestRequirePositiveOpening ensures zero opening deposits are rejected only where required.
+func TestRequirePositiveOpening(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.SetRequirePositiveOpening(GBP, true)
+
+	if _, err := bank.CreateAccount(1, 0, USD); err != nil {
+		t.Fatalf("expected zero opening to be allowed for USD, got %v", err)
+	}
+
+	_, err := bank.CreateAccount(1, 0, GBP)
+	if !errors.Is(err, ErrZeroOpening) {
+		t.Fatalf("expected ErrZeroOpening, got %v", err)
+	}
+
+	if _, err := bank.CreateAccount(1, 10, GBP); err != nil {
+		t.Fatalf("expected positive opening to be allowed for GBP, got %v", err)
+	}
+}
+
+// TestCodeOf ensures every predefined error maps to its stable code, including when wrapped.
+func TestCodeOf(t *testing.T) {
+	codes := map[error]string{
+		ErrNegativeDeposit:          "negative_deposit",
+		ErrAccountNotExist:          "account_not_found",
+		ErrUserNotExist:             "user_not_found",
+		ErrInvalidAmount:            "invalid_amount",
+		ErrInsufficientBalance:      "insufficient_balance",
+		ErrUnauthorizedAccess:       "unauthorized",
+		ErrCurrencyMismatch:         "currency_mismatch",
+		ErrExchangeRateNotFound:     "exchange_rate_not_found",
+		ErrInvalidRole:              "invalid_role",
+		ErrLockTimeout:              "lock_timeout",
+		ErrAuditChainBroken:         "audit_chain_broken",
+		ErrTransactionLimitExceeded: "transaction_limit_exceeded",
+		ErrAccountFrozen:            "account_frozen",
+		ErrLabelTooLong:             "label_too_long",
+		ErrMemoTooLong:              "memo_too_long",
+		ErrUnknownCurrency:          "unknown_currency",
+		ErrAccountIDInUse:           "account_id_in_use",
+		ErrUserInactive:             "user_inactive",
+		ErrAccountLimitReached:      "account_limit_reached",
+		ErrStaleExchangeRate:        "stale_exchange_rate",
+		ErrRateOutOfBounds:          "rate_out_of_bounds",
+		ErrMaxBalanceExceeded:       "max_balance_exceeded",
+		ErrZeroOpening:              "zero_opening_deposit",
+		ErrUnknownOperation:         "unknown_operation",
+		ErrAccountClosed:            "account_closed",
+		ErrAccountNotEmpty:          "account_not_empty",
+		ErrOrphanedAccount:          "orphaned_account",
+		ErrOwnerMismatch:            "owner_mismatch",
+		ErrInvalidBalance:           "invalid_balance",
+		ErrHoldNotFound:             "hold_not_found",
+		ErrBalanceOverflow:          "balance_overflow",
+		ErrUnknownCategory:          "unknown_category",
+		ErrVersionConflict:          "version_conflict",
+		ErrTransactionNotFound:      "transaction_not_found",
+		ErrRefundExceedsOriginal:    "refund_exceeds_original",
+		ErrInvalidRate:              "invalid_rate",
+		ErrUnbreakableAmount:        "unbreakable_amount",
+		ErrTransferRequestNotFound:  "transfer_request_not_found",
+		ErrExternalRefInUse:         "external_ref_in_use",
+		ErrInvalidPath:              "invalid_path",
+		ErrRateLimited:              "rate_limited",
+		ErrInvalidPrecision:         "invalid_precision",
+		ErrActiveHolds:              "active_holds",
+		ErrInvalidAllocation:        "invalid_allocation",
+
+		ErrScheduledTransferNotFound: "scheduled_transfer_not_found",
+	}
+	for err, code := range codes {
+		if got := CodeOf(err); got != code {
+			t.Errorf("expected code %q for %v, got %q", code, err, got)
+		}
+	}
+
+	if got := CodeOf(accountError(1, ErrAccountFrozen)); got != "account_frozen" {
+		t.Errorf("expected code of wrapped error to be account_frozen, got %q", got)
+	}
+	if got := CodeOf(errors.New("other")); got != "unknown" {
+		t.Errorf("expected unknown code, got %q", got)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"slices"
+	"sort"
+)
+
+// BankSnapshot is an immutable deep copy of the bank's state that reporting code can
+// traverse without holding any locks on the live service.
+type BankSnapshot struct {
+	Accounts      map[int]AccountInfo
+	Users         map[int]User
+	ExchangeRates map[string]float64
+}
+
+// SnapshotAll takes an internally consistent copy of all accounts, users and
+// exchange rates. It holds the bank lock and read locks on every account while
+// copying.
+func (b *BankService) SnapshotAll() BankSnapshot {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	snapshot := BankSnapshot{
+		Accounts:      make(map[int]AccountInfo, len(b.accounts)),
+		Users:         make(map[int]User, len(b.users)),
+		ExchangeRates: make(map[string]float64, len(b.exchangeRates)),
+	}
+
+	// Lock every account in ascending ID order, matching lockAccounts, so the
+	// balances form one consistent view without risking deadlock.
+	accountIDs := make([]int, 0, len(b.accounts))
+	for accountID := range b.accounts {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Ints(accountIDs)
+	for _, accountID := range accountIDs {
+		account := b.accounts[accountID]
+		account.mutex.RLock()
+		defer account.mutex.RUnlock()
+		snapshot.Accounts[accountID] = account.info(accountID)
+	}
+
+	for userID, user := range b.users {
+		userCopy := *user
+		userCopy.Accounts = append([]int(nil), user.Accounts...)
+		snapshot.Users[userID] = userCopy
+	}
+
+	for key, rate := range b.exchangeRates {
+		snapshot.ExchangeRates[key] = rate
+	}
+	return snapshot
+}
+
+// SnapshotBalances reads the balances of the given accounts as one consistent batch,
+// holding read locks on all of them at once rather than one at a time. Accounts that
+// don't exist are left out of the result.
+func (b *BankService) SnapshotBalances(accountIDs []int) map[int]float64 {
+	ids := slices.Clone(accountIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	// Look accounts up before locking any, so the bank lock is never taken while
+	// holding an account lock.
+	accounts := make(map[int]*Account, len(ids))
+	for _, accountID := range ids {
+		if account := b.lookupAccount(accountID); account != nil {
+			accounts[accountID] = account
+		}
+	}
+
+	// Lock in ascending ID order, matching lockAccounts.
+	for _, accountID := range ids {
+		if account, exists := accounts[accountID]; exists {
+			account.mutex.RLock()
+			defer account.mutex.RUnlock()
+		}
+	}
+
+	balances := make(map[int]float64, len(accounts))
+	for accountID, account := range accounts {
+		balances[accountID] = account.balance
+	}
+	return balances
+}
+
+// ServiceStatus summarizes the bank's state for an operations dashboard.
+type ServiceStatus struct {
+	Users           int
+	Accounts        int
+	FrozenAccounts  int
+	ClosedAccounts  int
+	TotalByCurrency map[string]float64 // Sum of account balances in each currency
+}
+
+// Status returns a summary of the bank taken from a consistent snapshot.
+func (b *BankService) Status() ServiceStatus {
+	snapshot := b.SnapshotAll()
+
+	status := ServiceStatus{
+		Users:           len(snapshot.Users),
+		Accounts:        len(snapshot.Accounts),
+		TotalByCurrency: make(map[string]float64),
+	}
+	for _, account := range snapshot.Accounts {
+		status.TotalByCurrency[account.Currency] += account.Balance
+		if account.Frozen {
+			status.FrozenAccounts++
+		}
+		if account.Closed {
+			status.ClosedAccounts++
+		}
+	}
+	return status
+}
+
+// FrozenAccounts returns the IDs, in ascending order, of frozen accounts, taken from
+// a consistent snapshot.
+func (b *BankService) FrozenAccounts() []int {
+	return b.accountsWhere(func(account AccountInfo) bool { return account.Frozen })
+}
+
+// ClosedAccounts returns the IDs, in ascending order, of closed accounts, taken from
+// a consistent snapshot.
+func (b *BankService) ClosedAccounts() []int {
+	return b.accountsWhere(func(account AccountInfo) bool { return account.Closed })
+}
+
+// accountsWhere returns the sorted IDs of the snapshotted accounts matching keep.
+func (b *BankService) accountsWhere(keep func(AccountInfo) bool) []int {
+	var ids []int
+	for accountID, account := range b.SnapshotAll().Accounts {
+		if keep(account) {
+			ids = append(ids, accountID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// TestSnapshotAll ensures the snapshot is unaffected by later changes to the bank.
+func TestSnapshotAll(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	acc1, _ := bank.CreateAccount(1, 1000, USD)
+	acc2, _ := bank.CreateAccount(1, 500, USD)
+	bank.SetExchangeRate(USD, EUR, 0.85)
+
+	snapshot := bank.SnapshotAll()
+
+	bank.Transfer(acc1, acc2, 300)
+	bank.CreateAccount(1, 100, EUR)
+	bank.CreateUser(2, Banker, false)
+	bank.SetExchangeRate(USD, EUR, 0.9)
+
+	if snapshot.Accounts[acc1].Balance != 1000 || snapshot.Accounts[acc2].Balance != 500 {
+		t.Errorf("expected snapshot balances 1000 and 500, got %.2f and %.2f",
+			snapshot.Accounts[acc1].Balance, snapshot.Accounts[acc2].Balance)
+	}
+	if len(snapshot.Accounts) != 2 || len(snapshot.Users) != 1 {
+		t.Errorf("expected 2 accounts and 1 user, got %d and %d", len(snapshot.Accounts), len(snapshot.Users))
+	}
+	if len(snapshot.Users[1].Accounts) != 2 {
+		t.Errorf("expected user 1 to own 2 accounts in snapshot, got %d", len(snapshot.Users[1].Accounts))
+	}
+	if snapshot.ExchangeRates[USD+":"+EUR] != 0.85 {
+		t.Errorf("expected snapshot rate 0.85, got %.2f", snapshot.ExchangeRates[USD+":"+EUR])
+	}
+}
+
+// TestStatus ensures the status counts users, accounts and totals.
+func TestStatus(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	bank.CreateAccount(1, 250, USD)
+	bank.CreateAccount(1, 80, EUR)
+	emptyID, _ := bank.CreateAccount(1, 0, EUR)
+	bank.FreezeAccount(2, acc1)
+	bank.CloseAccount(1, emptyID)
+
+	status := bank.Status()
+	if status.Users != 2 || status.Accounts != 4 || status.FrozenAccounts != 1 || status.ClosedAccounts != 1 {
+		t.Errorf("expected 2 users, 4 accounts, 1 frozen and 1 closed, got %+v", status)
+	}
+	expected := map[string]float64{USD: 350, EUR: 80}
+	if !maps.Equal(status.TotalByCurrency, expected) {
+		t.Errorf("expected totals %v, got %v", expected, status.TotalByCurrency)
+	}
+}
+
+// TestFrozenAndClosedAccounts ensures frozen and closed accounts are listed in order.
+func TestFrozenAndClosedAccounts(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Banker, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+	acc3, _ := bank.CreateAccount(1, 100, USD)
+	acc4, _ := bank.CreateAccount(1, 0, USD)
+	bank.FreezeAccount(2, acc3)
+	bank.FreezeAccount(2, acc1)
+	bank.CloseAccount(1, acc4)
+	bank.CloseAccount(1, acc2)
+
+	if frozen := bank.FrozenAccounts(); !slices.Equal(frozen, []int{acc1, acc3}) {
+		t.Errorf("expected frozen accounts %v, got %v", []int{acc1, acc3}, frozen)
+	}
+	if closed := bank.ClosedAccounts(); !slices.Equal(closed, []int{acc2, acc4}) {
+		t.Errorf("expected closed accounts %v, got %v", []int{acc2, acc4}, closed)
+	}
+}
+
+// TestSnapshotBalances ensures the batch snapshot matches individual balance reads.
+func TestSnapshotBalances(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 250, USD)
+	acc3, _ := bank.CreateAccount(1, 80, EUR)
+	bank.Transfer(acc1, acc2, 40)
+
+	balances := bank.SnapshotBalances([]int{acc3, acc1, acc2, acc1, 999})
+
+	expected := make(map[int]float64)
+	for _, accID := range []int{acc1, acc2, acc3} {
+		expected[accID], _, _ = bank.GetBalance(1, accID)
+	}
+	if !maps.Equal(balances, expected) {
+		t.Errorf("expected balances %v, got %v", expected, balances)
+	}
+}
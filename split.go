@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// allocationTolerance is how far allocation ratios may sum from 1.
+const allocationTolerance = 1e-9
+
+// Allocation is the share of a split deposit credited to one account.
+type Allocation struct {
+	AccountID int
+	Ratio     float64
+}
+
+// DepositSplit deposits total across the user's accounts in proportion to the
+// allocation ratios, which must sum to 1. Shares are rounded to the currency's minor
+// units and the rounding residue goes to the first allocation, so the shares add up
+// to total exactly. All accounts must share a currency, and either every share is
+// credited or none is.
+func (b *BankService) DepositSplit(userID int, allocations []Allocation, total float64) error {
+	defer b.observeDuration(OpDeposit, time.Now())
+
+	if total <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := checkAllocations(allocations); err != nil {
+		return err
+	}
+	for _, alloc := range allocations {
+		if err := b.CheckPermissions(userID, alloc.AccountID); err != nil {
+			return err
+		}
+	}
+	if err := b.checkTransactionLimit(userID, OpDeposit, total); err != nil {
+		return err
+	}
+	for _, alloc := range allocations {
+		if err := b.throttle(alloc.AccountID); err != nil {
+			return err
+		}
+	}
+
+	accounts := make([]*Account, len(allocations))
+	for i, alloc := range allocations {
+		accounts[i] = b.lookupAccount(alloc.AccountID)
+		if accounts[i].currency != accounts[0].currency {
+			return ErrCurrencyMismatch
+		}
+	}
+
+	shares := make([]float64, len(allocations))
+	residue := total
+	for i := 1; i < len(allocations); i++ {
+		shares[i] = b.roundToMinorUnits(total*allocations[i].Ratio, accounts[i].currency)
+		residue -= shares[i]
+	}
+	shares[0] = residue
+
+	if err := b.creditShares(allocations, accounts, shares); err != nil {
+		return err
+	}
+	for i, alloc := range allocations {
+		if shares[i] == 0 {
+			continue
+		}
+		fmt.Printf("User %d deposited %.2f to account %d\n", userID, shares[i], alloc.AccountID)
+		b.notify(Notification{Type: NotifyDeposit, AccountID: alloc.AccountID, Amount: shares[i], Message: fmt.Sprintf("Deposit of %.2f received", shares[i])})
+	}
+	return nil
+}
+
+// checkAllocations verifies the allocations name distinct accounts with positive
+// ratios summing to 1.
+func checkAllocations(allocations []Allocation) error {
+	if len(allocations) == 0 {
+		return ErrInvalidAllocation
+	}
+	seen := make(map[int]bool, len(allocations))
+	sum := 0.0
+	for _, alloc := range allocations {
+		if alloc.Ratio <= 0 || seen[alloc.AccountID] {
+			return ErrInvalidAllocation
+		}
+		seen[alloc.AccountID] = true
+		sum += alloc.Ratio
+	}
+	if math.Abs(sum-1) > allocationTolerance {
+		return ErrInvalidAllocation
+	}
+	return nil
+}
+
+// creditShares locks every allocated account in ascending ID order, verifies each
+// can take its share, and then credits them all.
+func (b *BankService) creditShares(allocations []Allocation, accounts []*Account, shares []float64) error {
+	ids := make([]int, len(allocations))
+	for i, alloc := range allocations {
+		ids[i] = alloc.AccountID
+	}
+	unlock, err := lockAll(ids, accounts, b.getLockTimeout())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, account := range accounts {
+		accountID := allocations[i].AccountID
+		if account.closed {
+			return accountError(accountID, ErrAccountClosed)
+		}
+		if err := account.checkCredit(accountID, shares[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, account := range accounts {
+		if shares[i] == 0 {
+			continue // A tiny ratio can round to nothing
+		}
+		account.balance += shares[i]
+		b.recordTransaction(allocations[i].AccountID, account, TxDeposit, shares[i], noCounterparty)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestDepositSplit ensures a split deposit is distributed by ratio and conserves the
+// total exactly.
+func TestDepositSplit(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	a, _ := bank.CreateAccount(1, 0, USD)
+	b, _ := bank.CreateAccount(1, 0, USD)
+	c, _ := bank.CreateAccount(1, 0, USD)
+
+	allocations := []Allocation{{AccountID: a, Ratio: 0.5}, {AccountID: b, Ratio: 0.3}, {AccountID: c, Ratio: 0.2}}
+	if err := bank.DepositSplit(1, allocations, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	total := 0.0
+	for accID, expected := range map[int]float64{a: 50, b: 30, c: 20} {
+		balance, _, _ := bank.GetBalance(1, accID)
+		if balance != expected {
+			t.Errorf("expected account %d to hold %.2f, got %.2f", accID, expected, balance)
+		}
+		total += balance
+	}
+	if total != 100 {
+		t.Errorf("expected the shares to add up to 100, got %v", total)
+	}
+}
+
+// TestDepositSplitResidue ensures the rounding residue goes to the first allocation.
+func TestDepositSplitResidue(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	a, _ := bank.CreateAccount(1, 0, USD)
+	b, _ := bank.CreateAccount(1, 0, USD)
+	c, _ := bank.CreateAccount(1, 0, USD)
+
+	third := 1.0 / 3
+	allocations := []Allocation{{AccountID: a, Ratio: third}, {AccountID: b, Ratio: third}, {AccountID: c, Ratio: third}}
+	if err := bank.DepositSplit(1, allocations, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	first, _, _ := bank.GetBalance(1, a)
+	second, _, _ := bank.GetBalance(1, b)
+	last, _, _ := bank.GetBalance(1, c)
+	if second != 33.33 || last != 33.33 {
+		t.Errorf("expected 33.33 in the other accounts, got %.2f and %.2f", second, last)
+	}
+	if first+second+last != 100 {
+		t.Errorf("expected the shares to add up to 100, got %v", first+second+last)
+	}
+}
+
+// TestDepositSplitInvalid ensures invalid splits are rejected without crediting any
+// account.
+func TestDepositSplitInvalid(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	usd, _ := bank.CreateAccount(1, 0, USD)
+	other, _ := bank.CreateAccount(1, 0, USD)
+	eur, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetMaxBalance(other, 10)
+
+	tests := []struct {
+		name        string
+		allocations []Allocation
+		expected    error
+	}{
+		{"empty", nil, ErrInvalidAllocation},
+		{"ratios below 1", []Allocation{{AccountID: usd, Ratio: 0.5}, {AccountID: other, Ratio: 0.4}}, ErrInvalidAllocation},
+		{"duplicate account", []Allocation{{AccountID: usd, Ratio: 0.5}, {AccountID: usd, Ratio: 0.5}}, ErrInvalidAllocation},
+		{"mixed currencies", []Allocation{{AccountID: usd, Ratio: 0.5}, {AccountID: eur, Ratio: 0.5}}, ErrCurrencyMismatch},
+		{"max balance", []Allocation{{AccountID: usd, Ratio: 0.5}, {AccountID: other, Ratio: 0.5}}, ErrMaxBalanceExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bank.DepositSplit(1, tt.allocations, 100)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if balance, _, _ := bank.GetBalance(1, usd); balance != 0 {
+				t.Errorf("expected no funds credited, got %.2f", balance)
+			}
+		})
+	}
+}
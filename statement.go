@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportStatementCSV writes the account's transactions in [from, to) as CSV with a
+// header row. Amounts and running balances use the transaction's currency and are
+// written to its precision.
+func (b *BankService) ExportStatementCSV(w io.Writer, userID, accountID int, from, to time.Time) error {
+	transactions, err := b.QueryTransactions(userID, accountID, TransactionFilter{From: from, To: to})
+	if err != nil {
+		return err
+	}
+	currency := b.lookupAccount(accountID).currency
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "type", "amount", "currency", "counterparty", "balance"})
+	for _, tx := range transactions {
+		txCurrency := currency
+		if tx.Currency != "" {
+			txCurrency = tx.Currency
+		}
+		decimals := b.getCurrency(txCurrency).MinorUnits
+		counterparty := ""
+		if tx.Counterparty != noCounterparty {
+			counterparty = strconv.Itoa(tx.Counterparty)
+		}
+
+		cw.Write([]string{
+			tx.Time.Format(time.RFC3339),
+			string(tx.Type),
+			strconv.FormatFloat(tx.Amount, 'f', decimals, 64),
+			txCurrency,
+			counterparty,
+			strconv.FormatFloat(tx.Balance, 'f', decimals, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DailyTurnover returns the total credited to and debited from the account on the
+// calendar day containing day, in day's time zone. Only amounts in the account's own
+// currency are counted.
+func (b *BankService) DailyTurnover(userID, accountID int, day time.Time) (credits, debits float64, err error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	transactions, err := b.QueryTransactions(userID, accountID, TransactionFilter{From: start, To: start.AddDate(0, 0, 1)})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, tx := range transactions {
+		switch {
+		case tx.Currency != "" || tx.Type == TxHoldExpired:
+			continue // Another currency's sub-balance, or no balance change
+		case tx.Type.isOutflow():
+			debits += tx.Amount
+		default:
+			credits += tx.Amount
+		}
+	}
+	return credits, debits, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExportStatementCSV ensures the statement lists the window's transactions as CSV.
+func TestExportStatementCSV(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+	start := clock.Now().Add(day)
+
+	clock.Advance(day)
+	bank.Transfer(acc1, acc2, 30)
+	clock.Advance(day)
+	bank.Withdraw(1, acc1, 20)
+	clock.Advance(day)
+	bank.Deposit(1, acc1, 5)
+
+	var sb strings.Builder
+	if err := bank.ExportStatementCSV(&sb, 1, acc1, start, clock.Now()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := "date,type,amount,currency,counterparty,balance\n" +
+		"2024-01-02T00:00:00Z,transfer_out,30.00,USD,1,70.00\n" +
+		"2024-01-03T00:00:00Z,withdrawal,20.00,USD,,50.00\n"
+	if sb.String() != expected {
+		t.Errorf("expected statement:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	if err := bank.ExportStatementCSV(&sb, 2, acc1, start, clock.Now()); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+}
+
+// TestExportStatementCSVPrecision ensures amounts are written to the currency's
+// configured precision.
+func TestExportStatementCSVPrecision(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.SetCurrencyPrecision("BTC", 8)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1, "BTC")
+
+	start := clock.Now()
+	clock.Advance(day)
+	bank.Withdraw(1, accID, 0.12345678)
+
+	var sb strings.Builder
+	if err := bank.ExportStatementCSV(&sb, 1, accID, start.Add(day), clock.Now().Add(day)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := "date,type,amount,currency,counterparty,balance\n" +
+		"2024-01-02T00:00:00Z,withdrawal,0.12345678,BTC,,0.87654322\n"
+	if sb.String() != expected {
+		t.Errorf("expected statement:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
+
+// TestDailyTurnover ensures only the day's credits and debits are totaled.
+func TestDailyTurnover(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+
+	clock.Advance(day + time.Hour)
+	bank.Deposit(1, acc1, 50)
+	bank.Transfer(acc1, acc2, 30)
+	clock.Advance(time.Hour)
+	bank.Withdraw(1, acc1, 20)
+	bank.Transfer(acc2, acc1, 10)
+	clock.Advance(day)
+	bank.Deposit(1, acc1, 500) // The next day
+
+	credits, debits, err := bank.DailyTurnover(1, acc1, clock.Now().Add(-day))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if credits != 60 || debits != 50 {
+		t.Errorf("expected credits 60 and debits 50, got %.2f and %.2f", credits, debits)
+	}
+
+	if _, _, err := bank.DailyTurnover(2, acc1, clock.Now()); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("expected ErrUnauthorizedAccess, got %v", err)
+	}
+}
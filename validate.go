@@ -0,0 +1,185 @@
+package main
+
+import "math"
+
+// CanWithdraw runs the validation and permission checks of Withdraw without moving
+// any funds, returning the error the real call would return.
+func (b *BankService) CanWithdraw(userID, accountID int, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, accountID); err != nil {
+		return err
+	}
+	if err := b.checkTransactionLimit(userID, OpWithdraw, amount); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	closed, frozen := account.closed, account.frozen
+	covered := account.available()+account.overdraftLimit >= amount
+	available := math.Max(account.available(), 0)
+	account.mutex.RUnlock()
+
+	if closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	if frozen && !b.bypassesFreeze(userID) {
+		return accountError(accountID, ErrAccountFrozen)
+	}
+	if covered {
+		return nil
+	}
+
+	policy := b.getBackupPolicy(userID)
+	if !policy.enabled {
+		return ErrInsufficientBalance
+	}
+	_, backups, err := b.backupAccounts(userID, accountID)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return ErrInsufficientBalance
+	}
+
+	tapped := 0
+	for _, backup := range backups {
+		if policy.maxAccounts > 0 && tapped == policy.maxAccounts {
+			break
+		}
+
+		backup.mutex.RLock()
+		if !backup.frozen && backup.available() > 0 {
+			available += backup.available()
+			tapped++
+		}
+		backup.mutex.RUnlock()
+	}
+	// Users allowing partial withdrawals get whatever the accounts can give, if any.
+	if available == 0 || (available < amount && !policy.partial) {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// WouldUseOverdraft reports whether withdrawing amount from the account would take
+// its balance into overdraft, and by how much, given the balance right now. A
+// withdrawal beyond the overdraft limit never uses it, falling back to backup funds
+// or failing instead; use CanWithdraw to check whether it would succeed.
+func (b *BankService) WouldUseOverdraft(userID, accountID int, amount float64) (bool, float64, error) {
+	if amount <= 0 {
+		return false, 0, ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, accountID); err != nil {
+		return false, 0, err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	available := account.available()
+	if amount <= available || amount > available+account.overdraftLimit {
+		return false, 0, nil
+	}
+	return true, amount - math.Max(available, 0), nil
+}
+
+// CanTransfer runs the validation checks of Transfer without moving any funds,
+// returning the error the real call would return.
+func (b *BankService) CanTransfer(fromID, toID int, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	fromAccount, err := b.getAccount(fromID)
+	if err != nil {
+		return err
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return err
+	}
+
+	if fromAccount.currency != toAccount.currency {
+		return ErrCurrencyMismatch
+	}
+	if err := b.checkActive(fromAccount.ownerID); err != nil {
+		return err
+	}
+	if err := b.checkTransactionLimit(fromAccount.ownerID, OpTransfer, amount); err != nil {
+		return err
+	}
+
+	toAccount.mutex.RLock()
+	closed := toAccount.closed
+	creditErr := toAccount.checkCredit(toID, amount)
+	toAccount.mutex.RUnlock()
+
+	fromAccount.mutex.RLock()
+	frozen := fromAccount.frozen
+	covered := fromAccount.available()+fromAccount.overdraftLimit >= amount
+	fromAccount.mutex.RUnlock()
+
+	// Report problems in the order Transfer checks them.
+	switch {
+	case frozen:
+		return accountError(fromID, ErrAccountFrozen)
+	case closed:
+		return accountError(toID, ErrAccountClosed)
+	case !covered:
+		return ErrInsufficientBalance
+	}
+	return creditErr
+}
+
+// CanExchange runs the validation and permission checks of ExchangeCurrency
+// without moving any funds, returning the error the real call would return.
+func (b *BankService) CanExchange(userID, fromID, toID int, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return err
+	}
+	if err := b.CheckPermissions(userID, toID); err != nil {
+		return err
+	}
+	if err := b.checkTransactionLimit(userID, OpExchange, amount); err != nil {
+		return err
+	}
+
+	fromAccount := b.lookupAccount(fromID)
+	toAccount := b.lookupAccount(toID)
+
+	if fromAccount.currency == toAccount.currency {
+		return b.CanTransfer(fromID, toID, amount)
+	}
+
+	if !b.isKnownCurrency(fromAccount.currency) || !b.isKnownCurrency(toAccount.currency) {
+		return ErrUnknownCurrency
+	}
+	if _, _, err := b.getTradingRate(fromAccount.currency, toAccount.currency); err != nil {
+		return err
+	}
+
+	return checkDebit(fromID, fromAccount, amount)
+}
+
+// checkDebit verifies the account is not frozen and can cover amount, including its
+// overdraft.
+func checkDebit(accountID int, account *Account, amount float64) error {
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	if account.frozen {
+		return accountError(accountID, ErrAccountFrozen)
+	}
+	if account.available()+account.overdraftLimit < amount {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCanWithdraw ensures the dry run reports the same errors as Withdraw.
+func TestCanWithdraw(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	bank.CreateUser(2, Customer, false)
+	bank.CreateUser(3, Banker, false)
+
+	accID, _ := bank.CreateAccount(1, 500, USD)
+	frozenID, _ := bank.CreateAccount(1, 500, USD)
+	bank.FreezeAccount(3, frozenID)
+
+	tests := []struct {
+		name      string
+		userID    int
+		accountID int
+		amount    float64
+		expected  error
+	}{
+		{"valid", 1, accID, 100, nil},
+		{"invalid amount", 1, accID, 0, ErrInvalidAmount},
+		{"unauthorized", 2, accID, 100, ErrUnauthorizedAccess},
+		{"missing account", 1, 42, 100, ErrAccountNotExist},
+		{"insufficient", 1, accID, 1000, ErrInsufficientBalance},
+		{"frozen", 1, frozenID, 100, ErrAccountFrozen},
+	}
+
+	for _, tt := range tests {
+		err := bank.CanWithdraw(tt.userID, tt.accountID, tt.amount)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+
+		// The real operation must agree with the dry run.
+		err = bank.Withdraw(tt.userID, tt.accountID, tt.amount)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected Withdraw to return %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+// TestCanWithdrawBackupAndOverdraft ensures backup funds and overdraft are considered.
+func TestCanWithdrawBackupAndOverdraft(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, true)
+
+	acc1, _ := bank.CreateAccount(1, 100, USD)
+	bank.CreateAccount(1, 100, USD)
+
+	if err := bank.CanWithdraw(1, acc1, 200); err != nil {
+		t.Errorf("expected backup funds to cover 200, got %v", err)
+	}
+	if err := bank.CanWithdraw(1, acc1, 250); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+
+	bank.SetOverdraftLimit(acc1, 200)
+	if err := bank.CanWithdraw(1, acc1, 250); err != nil {
+		t.Errorf("expected overdraft to cover 250, got %v", err)
+	}
+
+	balance, _, _ := bank.GetBalance(1, acc1)
+	if balance != 100 {
+		t.Errorf("expected dry run to leave balance at 100, got %.2f", balance)
+	}
+}
+
+// TestWouldUseOverdraft ensures the overdraft portion of a withdrawal is reported.
+func TestWouldUseOverdraft(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	bank.SetOverdraftLimit(accID, 200)
+
+	tests := []struct {
+		amount    float64
+		uses      bool
+		overdraft float64
+	}{
+		{80, false, 0},
+		{100, false, 0},
+		{150, true, 50},
+		{300, true, 200},
+		{301, false, 0}, // Beyond the limit, the withdrawal can't use overdraft
+	}
+	for _, tt := range tests {
+		uses, overdraft, err := bank.WouldUseOverdraft(1, accID, tt.amount)
+		if err != nil {
+			t.Fatalf("WouldUseOverdraft(%v): expected no error, got %v", tt.amount, err)
+		}
+		if uses != tt.uses || overdraft != tt.overdraft {
+			t.Errorf("WouldUseOverdraft(%v): expected %t, %.2f, got %t, %.2f", tt.amount, tt.uses, tt.overdraft, uses, overdraft)
+		}
+	}
+
+	if balance, _, _ := bank.GetBalance(1, accID); balance != 100 {
+		t.Errorf("expected balance 100, got %.2f", balance)
+	}
+}
+
+// TestCanTransfer ensures the dry run reports the same errors as Transfer.
+func TestCanTransfer(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 500, USD)
+	acc2, _ := bank.CreateAccount(1, 0, USD)
+	acc3, _ := bank.CreateAccount(1, 0, EUR)
+	capped, _ := bank.CreateAccount(1, 0, USD)
+	bank.SetMaxBalance(capped, 50)
+
+	tests := []struct {
+		name     string
+		fromID   int
+		toID     int
+		amount   float64
+		expected error
+	}{
+		{"valid", acc1, acc2, 100, nil},
+		{"invalid amount", acc1, acc2, -1, ErrInvalidAmount},
+		{"currency mismatch", acc1, acc3, 100, ErrCurrencyMismatch},
+		{"insufficient", acc1, acc2, 1000, ErrInsufficientBalance},
+		{"missing account", acc1, 42, 100, ErrAccountNotExist},
+		{"max balance exceeded", acc1, capped, 100, ErrMaxBalanceExceeded},
+	}
+
+	for _, tt := range tests {
+		err := bank.CanTransfer(tt.fromID, tt.toID, tt.amount)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+
+		err = bank.Transfer(tt.fromID, tt.toID, tt.amount)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected Transfer to return %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+// TestCanExchange ensures the dry run reports the same errors as ExchangeCurrency.
+func TestCanExchange(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	acc1, _ := bank.CreateAccount(1, 500, USD)
+	acc2, _ := bank.CreateAccount(1, 0, EUR)
+	acc3, _ := bank.CreateAccount(1, 0, GBP)
+	unregistered, _ := bank.CreateAccount(1, 0, "XYZ")
+	bank.SetExchangeRate(USD, EUR, 0.85)
+	bank.SetExchangeRate(USD, "XYZ", 2)
+
+	tests := []struct {
+		name     string
+		toID     int
+		amount   float64
+		expected error
+	}{
+		{"valid", acc2, 100, nil},
+		{"rate not found", acc3, 100, ErrExchangeRateNotFound},
+		{"unknown currency", unregistered, 100, ErrUnknownCurrency},
+		{"insufficient", acc2, 1000, ErrInsufficientBalance},
+	}
+
+	for _, tt := range tests {
+		err := bank.CanExchange(1, acc1, tt.toID, tt.amount)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+
+		err = bank.ExchangeCurrency(1, acc1, tt.toID, tt.amount)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected ExchangeCurrency to return %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+// TestCanExchangeOverdraft ensures exchanges draw on overdraft whether or not the
+// currencies differ, and the dry run agrees.
+func TestCanExchangeOverdraft(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	usdID, _ := bank.CreateAccount(1, 0, USD)
+	eurID, _ := bank.CreateAccount(1, 0, EUR)
+	bank.SetOverdraftLimit(accID, 200)
+	bank.SetExchangeRate(USD, EUR, 0.85)
+
+	for _, toID := range []int{usdID, eurID} {
+		if err := bank.CanExchange(1, accID, toID, 150); err != nil {
+			t.Errorf("account %d: expected overdraft to cover 150, got %v", toID, err)
+		}
+		if err := bank.CanExchange(1, accID, toID, 500); !errors.Is(err, ErrInsufficientBalance) {
+			t.Errorf("account %d: expected ErrInsufficientBalance beyond the overdraft, got %v", toID, err)
+		}
+	}
+	if err := bank.ExchangeCurrency(1, accID, usdID, 150); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.ExchangeCurrency(1, accID, eurID, 100); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if balance, _, _ := bank.GetBalance(1, accID); balance != -150 {
+		t.Errorf("expected balance -150, got %.2f", balance)
+	}
+}
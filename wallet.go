@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// EnableMultiCurrency turns an account into a wallet that can also hold balances
+// in currencies other than its own. The account's own currency stays the default
+// for all other operations.
+func (b *BankService) EnableMultiCurrency(userID, accountID int) error {
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if account.buckets == nil {
+		account.buckets = make(map[string]float64)
+	}
+	fmt.Printf("User %d enabled multiple currencies on account %d\n", userID, accountID)
+	return nil
+}
+
+// holds reports whether the account can hold a balance in the currency.
+// The caller must hold the account lock.
+func (a *Account) holds(currency string) bool {
+	return currency == a.currency || a.buckets != nil
+}
+
+// balanceIn returns the account's balance in the currency.
+// The caller must hold the account lock.
+func (a *Account) balanceIn(currency string) float64 {
+	if currency == "" || currency == a.currency {
+		return a.balance
+	}
+	return a.buckets[currency]
+}
+
+// spendableIn returns how much can be debited from the account's balance in the
+// currency: for its own currency, the available balance plus the overdraft.
+// The caller must hold the account lock.
+func (a *Account) spendableIn(currency string) float64 {
+	if currency == "" || currency == a.currency {
+		return a.available() + a.overdraftLimit
+	}
+	return a.buckets[currency]
+}
+
+// recordedCurrency returns the currency to record a transaction in: empty for the
+// account's own currency, so it is treated like any other balance change.
+func (a *Account) recordedCurrency(currency string) string {
+	if currency == a.currency {
+		return ""
+	}
+	return currency
+}
+
+// checkCreditIn verifies the account can take amount more in the currency. The
+// maximum balance is in the account's own currency, so other balances are only
+// checked for overflow. The caller must hold the account lock.
+func (a *Account) checkCreditIn(accountID int, currency string, amount float64) error {
+	if currency == "" || currency == a.currency {
+		return a.checkCredit(accountID, amount)
+	}
+	if math.IsInf(a.buckets[currency]+amount, 0) {
+		return accountError(accountID, ErrBalanceOverflow)
+	}
+	return nil
+}
+
+// adjust adds delta to the account's balance in the currency.
+// The caller must hold the account lock.
+func (a *Account) adjust(currency string, delta float64) {
+	if currency == a.currency {
+		a.balance += delta
+		return
+	}
+	a.buckets[currency] += delta
+}
+
+// DepositCurrency adds funds in the given currency to an account. Only accounts
+// with multiple currencies enabled accept currencies other than their own.
+func (b *BankService) DepositCurrency(userID, accountID int, amount float64, currency string) error {
+	defer b.observeDuration(OpDeposit, time.Now())
+
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.CheckPermissions(userID, accountID); err != nil {
+		return err
+	}
+	if err := b.checkTransactionLimit(userID, OpDeposit, amount); err != nil {
+		return err
+	}
+	if !b.isKnownCurrency(currency) {
+		return ErrUnknownCurrency
+	}
+
+	account := b.lookupAccount(accountID)
+	if currency == account.currency {
+		return b.Deposit(userID, accountID, amount)
+	}
+	if err := b.throttle(accountID); err != nil {
+		return err
+	}
+
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return err
+	}
+	defer account.mutex.Unlock()
+
+	if !account.holds(currency) {
+		return ErrCurrencyMismatch
+	}
+	if account.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	if err := account.checkCreditIn(accountID, currency, amount); err != nil {
+		return err
+	}
+
+	account.adjust(currency, amount)
+	b.record(accountID, account, Transaction{Type: TxDeposit, Amount: amount, Currency: currency, Counterparty: noCounterparty})
+	fmt.Printf("User %d deposited %s %.2f to account %d\n", userID, currency, amount, accountID)
+	return nil
+}
+
+// GetBalanceByCurrency retrieves an account's balance in the given currency.
+func (b *BankService) GetBalanceByCurrency(userID, accountID int, currency string) (float64, error) {
+	if err := b.CheckViewPermissions(userID, accountID); err != nil {
+		return 0, err
+	}
+
+	account := b.lookupAccount(accountID)
+	account.mutex.RLock()
+	defer account.mutex.RUnlock()
+
+	if !account.holds(currency) {
+		return 0, ErrCurrencyMismatch
+	}
+	return account.balanceIn(currency), nil
+}
+
+// TransferCurrency transfers funds in the given currency between two accounts that
+// can hold it, debiting and crediting the matching currency balances.
+func (b *BankService) TransferCurrency(userID, fromID, toID int, amount float64, currency string) error {
+	defer b.observeDuration(OpTransfer, time.Now())
+
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, fromID); err != nil {
+		return err
+	}
+	if err := b.checkTransactionLimit(userID, OpTransfer, amount); err != nil {
+		return err
+	}
+	if err := b.throttle(fromID); err != nil {
+		return err
+	}
+
+	toAccount, err := b.getAccount(toID)
+	if err != nil {
+		return err
+	}
+	fromAccount := b.lookupAccount(fromID)
+
+	defer b.queueAccounts(fromID, fromAccount, toID, toAccount)()
+	unlock, err := lockAccounts(fromID, fromAccount, toID, toAccount, b.getLockTimeout())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !fromAccount.holds(currency) || !toAccount.holds(currency) {
+		return ErrCurrencyMismatch
+	}
+	if fromAccount.closed {
+		return accountError(fromID, ErrAccountClosed)
+	}
+	if fromAccount.frozen {
+		return accountError(fromID, ErrAccountFrozen)
+	}
+	if toAccount.closed {
+		return accountError(toID, ErrAccountClosed)
+	}
+	if fromAccount.spendableIn(currency) < amount {
+		return ErrInsufficientBalance
+	}
+	if err := toAccount.checkCreditIn(toID, currency, amount); err != nil {
+		return err
+	}
+
+	fromAccount.adjust(currency, -amount)
+	toAccount.adjust(currency, amount)
+	b.record(fromID, fromAccount, Transaction{Type: TxTransferOut, Amount: amount, Currency: fromAccount.recordedCurrency(currency), Counterparty: toID})
+	b.record(toID, toAccount, Transaction{Type: TxTransferIn, Amount: amount, Currency: toAccount.recordedCurrency(currency), Counterparty: fromID})
+	fmt.Printf("Transferred %s %.2f from account %d to account %d\n", currency, amount, fromID, toID)
+	return nil
+}
+
+// ExchangeWithinAccount converts funds between two currency balances of a single
+// account with multiple currencies enabled.
+func (b *BankService) ExchangeWithinAccount(userID, accountID int, amount float64, fromCurrency, toCurrency string) error {
+	defer b.observeDuration(OpExchange, time.Now())
+
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := b.checkDebitPermissions(userID, accountID); err != nil {
+		return err
+	}
+	if err := b.checkTransactionLimit(userID, OpExchange, amount); err != nil {
+		return err
+	}
+	if err := b.throttle(accountID); err != nil {
+		return err
+	}
+
+	rate, stale, err := b.getTradingRate(fromCurrency, toCurrency)
+	if err != nil {
+		return err
+	}
+	if stale {
+		b.emit(Event{Type: EventStaleExchangeRate, AccountID: accountID, UserID: userID, Amount: amount})
+	}
+	credit := b.roundToMinorUnits(amount*rate, toCurrency)
+
+	account := b.lookupAccount(accountID)
+	if err := lockWithTimeout(&account.mutex, b.getLockTimeout()); err != nil {
+		return err
+	}
+	defer account.mutex.Unlock()
+
+	if !account.holds(fromCurrency) || !account.holds(toCurrency) {
+		return ErrCurrencyMismatch
+	}
+	if account.closed {
+		return accountError(accountID, ErrAccountClosed)
+	}
+	if account.frozen {
+		return accountError(accountID, ErrAccountFrozen)
+	}
+	if account.spendableIn(fromCurrency) < amount {
+		return ErrInsufficientBalance
+	}
+	if err := account.checkCreditIn(accountID, toCurrency, credit); err != nil {
+		return err
+	}
+
+	account.adjust(fromCurrency, -amount)
+	account.adjust(toCurrency, credit)
+	b.record(accountID, account, Transaction{Type: TxExchangeOut, Amount: amount, Currency: account.recordedCurrency(fromCurrency), Counterparty: accountID, Rate: rate})
+	b.record(accountID, account, Transaction{Type: TxExchangeIn, Amount: credit, Currency: account.recordedCurrency(toCurrency), Counterparty: accountID, Rate: rate})
+	fmt.Printf("Exchanged %.2f %s to %.2f %s in account %d\n", amount, fromCurrency, credit, toCurrency, accountID)
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestMultiCurrencyAccount ensures a wallet keeps separate balances per currency.
+func TestMultiCurrencyAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	walletID, _ := bank.CreateAccount(1, 100, USD)
+	plainID, _ := bank.CreateAccount(1, 100, USD)
+
+	if err := bank.DepositCurrency(1, plainID, 50, EUR); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected single-currency account to reject EUR, got %v", err)
+	}
+
+	if err := bank.EnableMultiCurrency(1, walletID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.DepositCurrency(1, walletID, 50, EUR); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.DepositCurrency(1, walletID, 25, USD); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	usd, _ := bank.GetBalanceByCurrency(1, walletID, USD)
+	eur, _ := bank.GetBalanceByCurrency(1, walletID, EUR)
+	gbp, _ := bank.GetBalanceByCurrency(1, walletID, GBP)
+	if usd != 125 || eur != 50 || gbp != 0 {
+		t.Errorf("expected balances 125 USD, 50 EUR and 0 GBP, got %.2f, %.2f and %.2f", usd, eur, gbp)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, walletID)
+	if deposit := history[1]; deposit.Currency != EUR || deposit.Balance != 50 {
+		t.Errorf("expected EUR deposit with balance 50, got %s %.2f", deposit.Currency, deposit.Balance)
+	}
+}
+
+// TestMultiCurrencyTransferAndExchange ensures transfers and exchanges act on the
+// specified currency balance.
+func TestMultiCurrencyTransferAndExchange(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	walletID, _ := bank.CreateAccount(1, 100, USD)
+	eurID, _ := bank.CreateAccount(1, 0, EUR)
+	bank.EnableMultiCurrency(1, walletID)
+	bank.SetExchangeRate(USD, EUR, 0.9)
+
+	if err := bank.ExchangeWithinAccount(1, walletID, 100, USD, EUR); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.TransferCurrency(1, walletID, eurID, 40, EUR); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.TransferCurrency(1, walletID, eurID, 40, USD); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	usd, _ := bank.GetBalanceByCurrency(1, walletID, USD)
+	eur, _ := bank.GetBalanceByCurrency(1, walletID, EUR)
+	received, _, _ := bank.GetBalance(1, eurID)
+	if usd != 0 || math.Abs(eur-50) > 1e-9 || received != 40 {
+		t.Errorf("expected wallet 0 USD and 50 EUR and 40 EUR received, got %.2f, %.2f and %.2f", usd, eur, received)
+	}
+	if err := bank.VerifyAuditChain(walletID); err != nil {
+		t.Errorf("expected intact audit chain, got %v", err)
+	}
+}
+
+// TestMultiCurrencyCreditLimits ensures credits to any currency balance respect the
+// maximum balance and can't overflow.
+func TestMultiCurrencyCreditLimits(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+
+	walletID, _ := bank.CreateAccount(1, 100, USD)
+	cappedID, _ := bank.CreateAccount(1, 0, EUR)
+	bank.EnableMultiCurrency(1, walletID)
+	bank.SetMaxBalance(walletID, 120)
+	bank.SetMaxBalance(cappedID, 30)
+	bank.SetExchangeRate(EUR, USD, 1.1)
+	bank.DepositCurrency(1, walletID, 50, EUR)
+
+	if err := bank.TransferCurrency(1, walletID, cappedID, 40, EUR); !errors.Is(err, ErrMaxBalanceExceeded) {
+		t.Errorf("expected ErrMaxBalanceExceeded for the transfer, got %v", err)
+	}
+	if err := bank.ExchangeWithinAccount(1, walletID, 50, EUR, USD); !errors.Is(err, ErrMaxBalanceExceeded) {
+		t.Errorf("expected ErrMaxBalanceExceeded for the exchange, got %v", err)
+	}
+	if err := bank.DepositCurrency(1, walletID, math.MaxFloat64, EUR); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := bank.DepositCurrency(1, walletID, math.MaxFloat64, EUR); !errors.Is(err, ErrBalanceOverflow) {
+		t.Errorf("expected ErrBalanceOverflow, got %v", err)
+	}
+
+	usd, _ := bank.GetBalanceByCurrency(1, walletID, USD)
+	received, _, _ := bank.GetBalance(1, cappedID)
+	if usd != 100 || received != 0 {
+		t.Errorf("expected 100 USD in the wallet and nothing received, got %.2f and %.2f", usd, received)
+	}
+}
+
+// TestMultiCurrencyOwnCurrency ensures wallet operations in the account's own
+// currency respect holds and overdraft, and are recorded like any other balance
+// change.
+func TestMultiCurrencyOwnCurrency(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+
+	walletID, _ := bank.CreateAccount(1, 100, USD)
+	otherID, _ := bank.CreateAccount(1, 0, USD)
+	bank.EnableMultiCurrency(1, walletID)
+	bank.SetExchangeRate(USD, EUR, 0.9)
+
+	holdID, _ := bank.PlaceHold(1, walletID, 100)
+	if err := bank.TransferCurrency(1, walletID, otherID, 100, USD); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected held funds to be unavailable, got %v", err)
+	}
+	if err := bank.ExchangeWithinAccount(1, walletID, 100, USD, EUR); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected held funds to be unavailable for exchange, got %v", err)
+	}
+	bank.ReleaseHold(holdID)
+
+	bank.SetOverdraftLimit(walletID, 50)
+	if err := bank.TransferCurrency(1, walletID, otherID, 120, USD); err != nil {
+		t.Fatalf("expected overdraft to cover the transfer, got %v", err)
+	}
+
+	history, _ := bank.GetTransactionHistory(1, walletID)
+	if last := history[len(history)-1]; last.Currency != "" {
+		t.Errorf("expected the own-currency transfer recorded without a currency, got %q", last.Currency)
+	}
+	if _, debits, _ := bank.DailyTurnover(1, walletID, clock.Now()); debits != 120 {
+		t.Errorf("expected debits 120, got %.2f", debits)
+	}
+}
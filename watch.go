@@ -0,0 +1,87 @@
+package main
+
+import "math"
+
+// watchBuffer is how many balance changes a watcher can fall behind by before
+// further changes are dropped.
+const watchBuffer = 64
+
+// BalanceChange describes how a transaction changed an account's balance.
+type BalanceChange struct {
+	AccountID int
+	Old       float64
+	New       float64
+	Delta     float64
+	Cause     TransactionType
+	Dropped   int // Changes the watcher missed just before this one
+}
+
+// watcher is a subscription to an account's balance changes.
+type watcher struct {
+	changes chan BalanceChange
+	dropped int // Changes dropped since the last one was sent
+}
+
+// WatchAccount returns a channel receiving every change to the account's balance,
+// including interest accrued without posting, and a function that stops the watch
+// and closes the channel. Changes are sent once they are applied, without blocking
+// the operation: if the watcher falls more than 64 changes behind, further changes
+// are dropped until it catches up, and the next change it receives reports how
+// many were dropped. Old on that change is the balance it was applied to, so the
+// watcher can resynchronize from it. Sub-balances of multi-currency accounts
+// aren't watched.
+func (b *BankService) WatchAccount(accountID int) (<-chan BalanceChange, func(), error) {
+	account, err := b.getAccount(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if account.watchers == nil {
+		account.watchers = make(map[int64]*watcher)
+	}
+	account.nextWatchID++
+	id := account.nextWatchID
+	changes := make(chan BalanceChange, watchBuffer)
+	account.watchers[id] = &watcher{changes: changes}
+
+	cancel := func() {
+		account.mutex.Lock()
+		defer account.mutex.Unlock()
+
+		if w, exists := account.watchers[id]; exists {
+			delete(account.watchers, id)
+			close(w.changes)
+		}
+	}
+	return changes, cancel, nil
+}
+
+// commit makes the account's balance visible to lock-free readers and watchers
+// after a change caused by cause, and bumps its version. Watchers are only told if
+// publish is set and the balance moved. The caller must hold the account lock.
+func (a *Account) commit(accountID int, cause TransactionType, publish bool) {
+	old := math.Float64frombits(a.committed.Load())
+	a.committed.Store(math.Float64bits(a.balance))
+	if publish && old != a.balance && len(a.watchers) > 0 {
+		a.publishChange(BalanceChange{AccountID: accountID, Old: old, New: a.balance, Delta: a.balance - old, Cause: cause})
+	}
+	a.version++
+}
+
+// publishChange sends a balance change to the account's watchers without blocking,
+// counting it as dropped for watchers that are behind. The caller must hold the
+// account lock.
+func (a *Account) publishChange(change BalanceChange) {
+	for _, w := range a.watchers {
+		change.Dropped = w.dropped
+		select {
+		case w.changes <- change:
+			w.dropped = 0
+		default:
+			w.dropped++
+		}
+	}
+}
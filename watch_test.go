@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWatchAccount ensures a watcher receives the account's balance changes until
+// it cancels the watch.
+func TestWatchAccount(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 100, USD)
+	otherID, _ := bank.CreateAccount(1, 100, USD)
+
+	changes, cancel, err := bank.WatchAccount(accID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	bank.Deposit(1, otherID, 10) // Another account's changes aren't sent
+	bank.Deposit(1, accID, 50)
+
+	select {
+	case change := <-changes:
+		expected := BalanceChange{AccountID: accID, Old: 100, New: 150, Delta: 50, Cause: TxDeposit}
+		if change != expected {
+			t.Errorf("expected %+v, got %+v", expected, change)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a balance change")
+	}
+
+	cancel()
+	bank.Withdraw(1, accID, 20)
+	if change, open := <-changes; open {
+		t.Errorf("expected the channel to be closed, got %+v", change)
+	}
+	cancel() // Canceling twice is harmless
+}
+
+// TestWatchAccountDeferredInterest ensures interest accrued without posting reaches
+// watchers, and posting it later doesn't report it again.
+func TestWatchAccountDeferredInterest(t *testing.T) {
+	clock := newFakeClock()
+	bank := NewBankService()
+	bank.SetClock(clock.Now)
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 1000, USD)
+	bank.SetInterestRate(accID, 0.05)
+	bank.SetDeferredInterestPosting(accID, true)
+
+	changes, cancel, _ := bank.WatchAccount(accID)
+	defer cancel()
+
+	clock.Advance(day)
+	bank.AccrueInterest()
+	bank.PostInterest(accID, clock.Now())
+
+	balance, _, _ := bank.GetBalance(1, accID)
+	select {
+	case change := <-changes:
+		if change.Cause != TxInterest || change.Old != 1000 || change.New != balance {
+			t.Errorf("expected interest taking the balance from 1000 to %.6f, got %+v", balance, change)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a balance change for the accrued interest")
+	}
+	select {
+	case change := <-changes:
+		t.Errorf("expected no change for posting, got %+v", change)
+	default:
+	}
+}
+
+// TestWatchAccountDropped ensures a watcher that fell behind is told how many
+// changes it missed.
+func TestWatchAccountDropped(t *testing.T) {
+	bank := NewBankService()
+	bank.CreateUser(1, Customer, false)
+	accID, _ := bank.CreateAccount(1, 0, USD)
+
+	changes, cancel, _ := bank.WatchAccount(accID)
+	defer cancel()
+
+	for i := 0; i < watchBuffer+3; i++ {
+		bank.Deposit(1, accID, 1)
+	}
+	for i := 0; i < watchBuffer; i++ {
+		if change := <-changes; change.Dropped != 0 {
+			t.Fatalf("change %d: expected nothing dropped, got %d", i, change.Dropped)
+		}
+	}
+
+	bank.Deposit(1, accID, 1)
+	change := <-changes
+	if change.Dropped != 3 {
+		t.Errorf("expected 3 dropped changes, got %d", change.Dropped)
+	}
+	if change.Old != watchBuffer+3 || change.New != watchBuffer+4 {
+		t.Errorf("expected the balance to go from %d to %d, got %+v", watchBuffer+3, watchBuffer+4, change)
+	}
+}